@@ -0,0 +1,411 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-league-crawler/pkg/storage"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Storage backends selectable with -storage
+const (
+	STORAGE_MONGO    = "mongo"
+	STORAGE_POSTGRES = "postgres"
+	STORAGE_SQLITE   = "sqlite"
+	STORAGE_FILE     = "file"
+	STORAGE_PARQUET  = "parquet"
+	STORAGE_NATS     = "nats"
+	STORAGE_S3       = "s3"
+)
+
+// writeHandlers are called with the ids of the documents of a kind that a storage wrote or failed to write in the background
+type writeHandlers struct {
+	failed  func(kind string, ids []string, err error)
+	written func(kind string, ids []string)
+}
+
+// openStorage returns the initialized DBManager described by spec, i.e. "mongo" (configured by the mongo flags),
+// "postgres:<dsn>", "sqlite:<path>", "file:<dir>[?<options>]", "parquet:<dir>[?max-rows=<n>&max-age=<duration>]", "nats://<host>:<port>[?<options>]"
+// or "s3://<bucket>[/<prefix>][?<options>]".
+// A positive batchSize makes the backend write in bulks, which are reported to handlers unless it is nil
+func openStorage(spec string, mongoConfig *mongoFlags, batchSize int, flushInterval time.Duration, handlers *writeHandlers) (storage.DBManager, error) {
+	kind, dsn := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, dsn = spec[:i], spec[i+1:]
+	}
+	switch kind {
+	case STORAGE_MONGO:
+		mongoOpts := []storage.MongoOption{}
+		if batchSize > 0 {
+			mongoOpts = append(mongoOpts, storage.WithBatching(batchSize, flushInterval))
+		}
+		mm, err := mongoConfig.manager(mongoOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if handlers != nil {
+			kinds := map[string]string{
+				mm.MatchStorage:     storage.KIND_MATCH,
+				mm.PlayerStorage:    storage.KIND_PLAYER,
+				mm.TFTMatchStorage:  storage.KIND_TFT_MATCH,
+				mm.TFTPlayerStorage: storage.KIND_TFT_PLAYER,
+			}
+			mm.OnWriteError = func(coll string, models []mongo.WriteModel, err error) {
+				handlers.failed(kinds[coll], storage.ModelIDs(models), err)
+			}
+			mm.OnWrite = func(coll string, models []mongo.WriteModel) {
+				handlers.written(kinds[coll], storage.ModelIDs(models))
+			}
+		}
+		return mm, mm.Init()
+	case STORAGE_POSTGRES, "postgresql":
+		if strings.HasPrefix(dsn, "//") {
+			// The spec is a postgres:// url itself
+			dsn = spec
+		}
+		sm, err := storage.NewPostgresManager(dsn, sqlOptions(batchSize, flushInterval)...)
+		if err != nil {
+			return nil, err
+		}
+		sm.CrawlerVersion = Version
+		handleSQLWrites(sm, handlers)
+		return sm, sm.Init()
+	case STORAGE_SQLITE:
+		if dsn == "" {
+			return nil, fmt.Errorf("Storage %v lacks the path of the database file, e.g. sqlite:./crawl.db", spec)
+		}
+		sm, err := storage.NewSQLiteManager(dsn, sqlOptions(batchSize, flushInterval)...)
+		if err != nil {
+			return nil, err
+		}
+		sm.CrawlerVersion = Version
+		handleSQLWrites(sm, handlers)
+		return sm, sm.Init()
+	case STORAGE_FILE:
+		fm, err := fileManager(dsn)
+		if err != nil {
+			return nil, err
+		}
+		fm.CrawlerVersion = Version
+		return fm, fm.Init()
+	case STORAGE_PARQUET:
+		pm, err := parquetManager(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return pm, pm.Init()
+	case STORAGE_NATS:
+		np, err := natsPublisher(spec)
+		if err != nil {
+			return nil, err
+		}
+		np.CrawlerVersion = Version
+		return np, np.Init()
+	case STORAGE_S3:
+		sm, err := s3Manager(spec)
+		if err != nil {
+			return nil, err
+		}
+		sm.CrawlerVersion = Version
+		return sm, sm.Init()
+	default:
+		return nil, fmt.Errorf("Unknown storage %v", spec)
+	}
+}
+
+// storageSpecs collects the values of a repeatable storage flag
+type storageSpecs struct {
+	specs []string
+	def   string
+}
+
+// storagesFlag registers a repeatable storage flag, falling back to def if it is not given
+func storagesFlag(fs *flag.FlagSet, name string, def string, usage string) *storageSpecs {
+	s := &storageSpecs{def: def}
+	fs.Var(s, name, fmt.Sprintf("%v (default %q)", usage, def))
+	return s
+}
+
+func (s *storageSpecs) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(s.specs, " ")
+}
+
+func (s *storageSpecs) Set(spec string) error {
+	s.specs = append(s.specs, spec)
+	return nil
+}
+
+// values returns the given specs or the default
+func (s *storageSpecs) values() []string {
+	if len(s.specs) == 0 {
+		return []string{s.def}
+	}
+	return s.specs
+}
+
+// openStorages returns a DBManager writing to every storage described by specs. A single storage is returned as is,
+// several ones are combined by a CompositeManager. Each spec may be prefixed by the failure policy of its storage,
+// e.g. best-effort:file:./archive, storages being required by default. A best-effort storage that cannot be opened is left out
+func openStorages(specs []string, mongoConfig *mongoFlags, batchSize int, flushInterval time.Duration, handlers *writeHandlers) (storage.DBManager, error) {
+	if len(specs) == 1 {
+		if _, spec := storagePolicy(specs[0]); spec == specs[0] {
+			return openStorage(spec, mongoConfig, batchSize, flushInterval, handlers)
+		}
+	}
+	sinks := []storage.Sink{}
+	for _, spec := range specs {
+		policy, spec := storagePolicy(spec)
+		var required *writeHandlers
+		if policy == storage.REQUIRED {
+			required = handlers
+		}
+		dbm, err := openStorage(spec, mongoConfig, batchSize, flushInterval, required)
+		if err != nil {
+			if policy == storage.BEST_EFFORT {
+				log.Warnf("Leaving out best-effort storage %v: %v", storageName(spec), err)
+				continue
+			}
+			for _, sink := range sinks {
+				if closer, ok := sink.DBManager.(io.Closer); ok {
+					closer.Close()
+				}
+			}
+			return nil, fmt.Errorf("Could not open storage %v: %v", storageName(spec), err)
+		}
+		sinks = append(sinks, storage.Sink{Name: storageName(spec), Policy: policy, DBManager: dbm})
+	}
+	return storage.NewCompositeManager(sinks...)
+}
+
+// storagePolicy splits the failure policy prefix off a spec
+func storagePolicy(spec string) (string, string) {
+	for _, policy := range []string{storage.REQUIRED, storage.BEST_EFFORT} {
+		if strings.HasPrefix(spec, policy+":") {
+			return policy, spec[len(policy)+1:]
+		}
+	}
+	return storage.REQUIRED, spec
+}
+
+func handleSQLWrites(sm *storage.SQLManager, handlers *writeHandlers) {
+	if handlers == nil {
+		return
+	}
+	sm.OnWriteError = func(matchIDs []string, puuids []string, err error) {
+		handlers.failed(storage.KIND_MATCH, matchIDs, err)
+		handlers.failed(storage.KIND_PLAYER, puuids, err)
+	}
+	sm.OnWrite = func(matchIDs []string, puuids []string) {
+		handlers.written(storage.KIND_MATCH, matchIDs)
+		handlers.written(storage.KIND_PLAYER, puuids)
+	}
+}
+
+// writesAsync reports whether a required storage of specs writes matches and players in the background,
+// so that they only count as crawled once it reports them as written
+func writesAsync(specs []string, batchSize int) bool {
+	if batchSize <= 0 {
+		return false
+	}
+	for _, spec := range specs {
+		policy, spec := storagePolicy(spec)
+		switch storageName(spec) {
+		case STORAGE_MONGO, STORAGE_POSTGRES, "postgresql", STORAGE_SQLITE:
+			if policy == storage.REQUIRED {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sqlOptions(batchSize int, flushInterval time.Duration) []storage.SQLOption {
+	if batchSize > 0 {
+		return []storage.SQLOption{storage.WithSQLBatching(batchSize, flushInterval)}
+	}
+	return nil
+}
+
+// storageName returns the kind of storage of a spec, leaving out credentials that may be part of its dsn
+func storageName(spec string) string {
+	policy, spec := storagePolicy(spec)
+	if policy == storage.BEST_EFFORT {
+		return policy + ":" + storageName(spec)
+	}
+	if i := strings.Index(spec, ":"); i >= 0 {
+		return spec[:i]
+	}
+	return spec
+}
+
+// storageNames returns the names of several specs
+func storageNames(specs []string) string {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = storageName(spec)
+	}
+	return strings.Join(names, ", ")
+}
+
+// fileManager returns a FileManager for a spec of the form <dir>?compression=zstd&partition=date,patch&max-size=256MB&max-age=1h,
+// the options being optional
+func fileManager(spec string) (*storage.FileManager, error) {
+	dir, query := spec, ""
+	if i := strings.Index(spec, "?"); i >= 0 {
+		dir, query = spec[:i], spec[i+1:]
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("File storage lacks a directory, e.g. file:./crawl")
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	opts := []storage.FileOption{}
+	if c := values.Get("compression"); c != "" {
+		opts = append(opts, storage.WithFileCompression(c))
+	}
+	if p, ok := values["partition"]; ok {
+		keys := []string{}
+		if p[0] != "" {
+			keys = strings.Split(p[0], ",")
+		}
+		opts = append(opts, storage.WithPartitions(keys...))
+	}
+	maxSize, maxAge := int64(storage.DefaultFileMaxSize), storage.DefaultFileMaxAge
+	if s := values.Get("max-size"); s != "" {
+		if maxSize, err = parseSize(s); err != nil {
+			return nil, err
+		}
+	}
+	if a := values.Get("max-age"); a != "" {
+		if maxAge, err = time.ParseDuration(a); err != nil {
+			return nil, err
+		}
+	}
+	opts = append(opts, storage.WithRotation(maxSize, maxAge))
+	return storage.NewFileManager(dir, opts...)
+}
+
+// parquetManager returns a ParquetManager for a spec of the form <dir>[?max-rows=<n>&max-age=<duration>]
+func parquetManager(spec string) (*storage.ParquetManager, error) {
+	dir, query := spec, ""
+	if i := strings.Index(spec, "?"); i >= 0 {
+		dir, query = spec[:i], spec[i+1:]
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("Parquet storage lacks a directory, e.g. parquet:./parquet")
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	opts := []storage.ParquetOption{}
+	if r := values.Get("max-rows"); r != "" {
+		maxRows, err := strconv.ParseInt(r, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, storage.WithMaxRows(maxRows))
+	}
+	if a := values.Get("max-age"); a != "" {
+		maxAge, err := time.ParseDuration(a)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, storage.WithMaxAge(maxAge))
+	}
+	return storage.NewParquetManager(dir, opts...)
+}
+
+// natsPublisher returns a NATSPublisher for a spec of the form
+// nats://<host>:<port>?match-subject=lol.matches&player-subject=lol.players&delivery=at-least-once&outbox=./outbox.jsonl,
+// the options being optional
+func natsPublisher(spec string) (*storage.NATSPublisher, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = u.Host + ":4222"
+	}
+	values := u.Query()
+	opts := []storage.NATSOption{}
+	if values.Get("match-subject") != "" || values.Get("player-subject") != "" {
+		matchSubject, playerSubject := storage.DefaultMatchSubject, storage.DefaultPlayerSubject
+		if s := values.Get("match-subject"); s != "" {
+			matchSubject = s
+		}
+		if s := values.Get("player-subject"); s != "" {
+			playerSubject = s
+		}
+		opts = append(opts, storage.WithSubjects(matchSubject, playerSubject))
+	}
+	if d := values.Get("delivery"); d != "" {
+		opts = append(opts, storage.WithDelivery(d))
+	}
+	if o := values.Get("outbox"); o != "" {
+		opts = append(opts, storage.WithOutbox(o))
+	}
+	return storage.NewNATSPublisher(addr, opts...)
+}
+
+// s3Manager returns an S3Manager for a spec of the form
+// s3://<bucket>/<prefix>?endpoint=http://localhost:9000&region=us-east-1&compression=gzip&manifest-size=1000,
+// the options being optional. The credentials are taken from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+func s3Manager(spec string) (*storage.S3Manager, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	values := u.Query()
+	endpoint := values.Get("endpoint")
+	opts := []storage.S3Option{storage.WithS3Credentials(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))}
+	region := values.Get("region")
+	if region == "" {
+		region = storage.DefaultS3Region
+	}
+	opts = append(opts, storage.WithS3Region(region))
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	if c := values.Get("compression"); c != "" {
+		opts = append(opts, storage.WithObjectCompression(c))
+	}
+	if m := values.Get("manifest-size"); m != "" {
+		size, err := strconv.Atoi(m)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, storage.WithManifestSize(size))
+	}
+	return storage.NewS3Manager(endpoint, u.Host, u.Path, opts...)
+}
+
+// parseSize parses a number of bytes with an optional unit, e.g. 512KB, 256MB or 1GB
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), 10, 64)
+			return n * u.factor, err
+		}
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"go-league-crawler/pkg/storage"
+	"io"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// exportCSV writes the selected columns of every participant (or team) of the stored matches passing the filters
+func exportCSV(args []string) error {
+	fs := flag.NewFlagSet("export csv", flag.ExitOnError)
+	mongoConfig := addMongoFlags(fs)
+	fromPtr := fs.String("from", STORAGE_MONGO, "Storage to read the matches from, given like -storage of the crawler")
+	outPtr := fs.String("out", "-", "File to write the csv to (- for stdout)")
+	fieldsPtr := fs.String("fields", "metadata.matchId,info.gameVersion,participants.championName,participants.win", "Comma separated field paths of the columns, e.g. info.queueId,participants.kills,teams.objectives.baron.kills")
+	levelPtr := fs.String("level", storage.LEVEL_PARTICIPANT, "Whether a row is written per participant or per team")
+	puuidPtr := fs.String("puuid", "", "Only export matches this player participated in")
+	queuePtr := fs.Int("queue", 0, "Only export matches of this queue, e.g. 420 (0 exports all queues)")
+	patchPtr := fs.String("patch", "", "Only export matches of this patch, e.g. 14.2")
+	sincePtr := fs.String("since", "", "Only export matches created on or after this date (YYYY-MM-DD, UTC)")
+	untilPtr := fs.String("until", "", "Only export matches created before this date (YYYY-MM-DD, UTC)")
+	fs.Parse(args)
+
+	paths, err := storage.ParseFieldPaths(*fieldsPtr, *levelPtr)
+	if err != nil {
+		return err
+	}
+	query := storage.MatchQuery{Puuid: *puuidPtr, QueueID: *queuePtr, Patch: *patchPtr}
+	for _, d := range []struct {
+		value string
+		t     *time.Time
+	}{{*sincePtr, &query.Since}, {*untilPtr, &query.Until}} {
+		if d.value == "" {
+			continue
+		}
+		if *d.t, err = time.Parse("2006-01-02", d.value); err != nil {
+			return err
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if *outPtr == "-" {
+		// Keep the log out of the csv
+		log.SetOutput(os.Stderr)
+	} else {
+		file, err := os.Create(*outPtr)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	cw := storage.NewCSVWriter(out, paths, *levelPtr)
+	if err := cw.WriteHeader(); err != nil {
+		return err
+	}
+	n, err := exportMatches(*fromPtr, mongoConfig, query, cw)
+	if ferr := cw.Flush(); err == nil {
+		err = ferr
+	}
+	log.Infof("Exported %d rows of %d matches", cw.Rows(), n)
+	return err
+}
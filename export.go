@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// exporters maps the formats of the export command to their implementation
+var exporters = map[string]func(args []string) error{
+	"parquet": exportParquet,
+	"csv":     exportCSV,
+}
+
+// export writes the stored matches of any storage into another format, e.g. export parquet -from sqlite:./crawl.db
+func export(args []string) error {
+	if len(args) == 0 || exporters[args[0]] == nil {
+		return fmt.Errorf("Usage: export <format> [flags], format being one of parquet or csv")
+	}
+	return exporters[args[0]](args[1:])
+}
+
+// exportParquet writes a row per participant of every stored match into Parquet files partitioned by patch
+func exportParquet(args []string) error {
+	fs := flag.NewFlagSet("export parquet", flag.ExitOnError)
+	mongoConfig := addMongoFlags(fs)
+	fromPtr := fs.String("from", STORAGE_MONGO, "Storage to read the matches from, given like -storage of the crawler")
+	outPtr := fs.String("out", "./parquet", "Directory to write the Parquet files to")
+	maxRowsPtr := fs.Int64("max-rows", storage.DefaultParquetMaxRows, "Maximum number of rows per Parquet file")
+	fs.Parse(args)
+
+	pm, err := storage.NewParquetManager(*outPtr, storage.WithMaxRows(*maxRowsPtr))
+	if err != nil {
+		return err
+	}
+	if err := pm.Init(); err != nil {
+		return err
+	}
+	n, err := exportMatches(*fromPtr, mongoConfig, storage.MatchQuery{}, pm)
+	if cerr := pm.Close(); err == nil {
+		err = cerr
+	}
+	log.Infof("Exported %d matches to %v", n, *outPtr)
+	return err
+}
+
+// exportMatches reads the matches selected by query from the storage given by spec and inserts them into dst. It returns the number of matches read
+func exportMatches(spec string, mongo *mongoFlags, query storage.MatchQuery, dst storage.DBManager) (int, error) {
+	src, err := openStorage(spec, mongo, 0, 0, nil)
+	if closer, ok := src.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = storage.FindMatches(src, query, func(match types.Match) error {
+		n++
+		return dst.InsertMatch(match)
+	})
+	if errors.Is(err, storage.ErrUnsupported) {
+		return n, fmt.Errorf("Storage %v is not able to read matches back", storageName(spec))
+	}
+	return n, err
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
+	"go-league-crawler/pkg/crawler"
 	"go-league-crawler/pkg/logging"
+	"go-league-crawler/pkg/riot"
 	"go-league-crawler/pkg/storage"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
 )
 
+// Version of the crawler, stamped onto every stored document
+const Version = "0.3.0"
+
 // TODOs:
 // command line parameters
 // Properly implement the "worker paradigm"
@@ -26,66 +34,187 @@ var (
 	// Number of concurrent threads the Crawler will utilize
 
 	// DB Manager Properties
+	storageSpec      string = STORAGE_MONGO
 	host             string = "127.0.0.1"
 	dbname           string = "go-league-crawler-test"
 	matchCollection  string = "matches"
 	playerCollection string = "players"
+	batchSize        int    = 0
+	flushInterval           = 500 * time.Millisecond
 
 	// Crawler Properties
+	mode               string = "lol"
 	platform           string = "EUW"
 	startPlayer        string = "dwaynehart"
 	concurrency        int    = 6
 	minNumberOfMatches int    = 100
 	minNumberOfPlayers int    = 0
+	featuredInterval          = time.Duration(0)
+	statusInterval            = time.Duration(0)
+	challenges         bool   = false
+	archive            string = ""
+	compression        string = storage.GZIP
+	dryRun             bool   = false
+	deadLetters        string = "./dead-letters.jsonl"
 
 	// Command Line Flag Pointers
-	hostPtr               *string = flag.String("host", host, "Host of the Target DB")
-	dbnamePtr             *string = flag.String("db", dbname, "Name of the Target DB")
-	matchCollectionPtr    *string = flag.String("mc", matchCollection, "Collection where to ingest the match data into")
-	playerCollectionPtr   *string = flag.String("pc", playerCollection, "Collection where to ingest the player data into")
+	storagesPtr                   = storagesFlag(flag.CommandLine, "storage", storageSpec, "Where to store the crawled data: mongo (configured by -host, -mongo-uri, ...), postgres:<dsn>, sqlite:<path>, file:<dir>[?compression=zstd&partition=date,platform,patch&max-size=128MB&max-age=1h], parquet:<dir>[?max-rows=1000000&max-age=1h], nats://<host>:<port>[?match-subject=lol.matches&player-subject=lol.players&delivery=at-least-once&outbox=./outbox.jsonl] or s3://<bucket>[/<prefix>][?endpoint=http://localhost:9000&region=us-east-1&compression=gzip&manifest-size=1000]. Repeat it to write to several storages, prefixing those whose failures should only be logged with best-effort:")
+	mongoConfig                   = addMongoFlags(flag.CommandLine)
+	batchSizePtr          *int    = flag.Int("batch-size", batchSize, "Write matches and players asynchronously in bulks of this size (0 writes every document synchronously)")
+	flushIntervalPtr              = flag.Duration("flush-interval", flushInterval, "Maximum time a document waits for its bulk to be written")
+	modePtr               *string = flag.String("mode", mode, "What to crawl: lol (ranked matches), clash (clash teams and their matches) or tft (tft matches)")
 	platformPtr           *string = flag.String("pl", platform, "Region to crawl data from")
 	startPlayerPtr        *string = flag.String("s", startPlayer, "Player with whom to begin to crawl data from")
 	concurrencyPtr        *int    = flag.Int("con", concurrency, "Degree of Concurrency (No. of Threads)")
 	minNumberOfMatchesPtr *int    = flag.Int("m", minNumberOfMatches, "Minimum Number of Matches to Crawl before terminating")
 	minNumberOfPlayersPtr *int    = flag.Int("p", minNumberOfPlayers, "Minimum Players of Matches to Crawl before terminating")
+	featuredIntervalPtr           = flag.Duration("featured", featuredInterval, "Interval in which featured games are polled for new players (0 disables polling)")
+	challengesPtr         *bool   = flag.Bool("challenges", challenges, "Fetch and store the challenge progress of every crawled player")
+	archivePtr            *string = flag.String("archive", archive, "Keep the raw api responses of matches and players: both (next to the typed documents), raw (instead of the typed documents) or empty (disabled)")
+	compressionPtr        *string = flag.String("compression", compression, "Compression of the raw api responses: gzip or zstd")
+	statusIntervalPtr             = flag.Duration("status", statusInterval, "Interval in which the platform status is polled to pause crawling during maintenances (0 disables polling)")
+	deadLettersPtr        *string = flag.String("dead-letters", deadLetters, "File to append the matches and players that could not be stored to")
+	dryRunPtr             *bool   = flag.Bool("dry-run", dryRun, "Keep the crawled data in memory instead of storing it, ignoring -storage")
 )
 
+// commands maps the names of subcommands to their implementation. Without a subcommand, the crawler is started
+var commands = map[string]func(args []string) error{
+	"reprocess":    reprocess,
+	"migrate":      migrate,
+	"export":       export,
+	"retry-failed": retryFailed,
+}
+
 func main() {
 	// Init Logger
 	logging.InitLogger("./log/logfile.log")
 
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
 	// Parse Command Line Flags and log them
 	flag.Parse()
 	log.WithFields(log.Fields{
-		"Host":                     *hostPtr,
-		"DB":                       *dbnamePtr,
-		"Matches":                  *matchCollectionPtr,
-		"Players":                  *playerCollectionPtr,
+		"Storage":                  storageNames(storagesPtr.values()),
+		"Host":                     *mongoConfig.host,
+		"URI Given":                *mongoConfig.uri != "",
+		"DB":                       *mongoConfig.dbname,
+		"Matches":                  *mongoConfig.matchCollection,
+		"Players":                  *mongoConfig.playerCollection,
+		"Batch Size":               *batchSizePtr,
+		"Flush Interval":           *flushIntervalPtr,
+		"Mode":                     *modePtr,
 		"Platform":                 *platformPtr,
 		"Starting Player":          *startPlayerPtr,
 		"Concurrency Level":        *concurrencyPtr,
 		"Minimum Matches to Crawl": *minNumberOfMatchesPtr,
 		"Minimum Players to Crawl": *minNumberOfPlayersPtr,
+		"Featured Games Interval":  *featuredIntervalPtr,
+		"Platform Status Interval": *statusIntervalPtr,
+		"Challenges":               *challengesPtr,
+		"Archive":                  *archivePtr,
+		"Compression":              *compressionPtr,
+		"Dry Run":                  *dryRunPtr,
+		"Dead Letters":             *deadLettersPtr,
 	}).Info("Started Crawler with the following parameters")
 	now := time.Now()
 
-	// Init DB Manager
-	mm := storage.NewMManager(host, dbname, matchCollection, playerCollection)
-	defer mm.Client.Disconnect(context.Background())
-	err := mm.Init()
+	// Init DB Manager, whose background writes are reported to the crawler once it exists
+	var EUWCrawler *crawler.Crawler
+	handlers := &writeHandlers{
+		failed: func(kind string, ids []string, err error) {
+			if EUWCrawler == nil {
+				log.Errorf("Could not store %d documents of kind %v: %v", len(ids), kind, err)
+				return
+			}
+			EUWCrawler.WriteFailed(kind, ids, err)
+		},
+		written: func(kind string, ids []string) {
+			if EUWCrawler != nil {
+				EUWCrawler.WriteSucceeded(kind, ids)
+			}
+		},
+	}
+	var dbm storage.DBManager
+	var err error
+	if *dryRunPtr {
+		memory := storage.NewMemoryManager()
+		memory.CrawlerVersion = Version
+		defer func() {
+			log.WithFields(log.Fields{"Documents": memory.Counts()}).Info("Dry run finished, nothing has been stored")
+		}()
+		dbm = memory
+	} else {
+		dbm, err = openStorages(storagesPtr.values(), mongoConfig, *batchSizePtr, *flushIntervalPtr, handlers)
+	}
 	if err != nil {
 		panic(err)
 	}
 	// Init Crawler
-	EUWCrawler, err := NewCrawler(
+	opts := []crawler.Option{
+		crawler.WithMinNumberOfMatches(*minNumberOfMatchesPtr),
+		crawler.WithMinNumberOfPlayers(*minNumberOfPlayersPtr),
+		crawler.WithFeaturedGamesInterval(*featuredIntervalPtr),
+		crawler.WithPlatformStatusInterval(*statusIntervalPtr),
+		crawler.WithDeadLetters(*deadLettersPtr),
+	}
+	async := !*dryRunPtr && writesAsync(storagesPtr.values(), *batchSizePtr)
+	if async {
+		opts = append(opts, crawler.WithAsyncWrites())
+	}
+	switch *modePtr {
+	case "lol":
+	case "clash":
+		opts = append(opts, crawler.WithClash())
+	case "tft":
+		opts = append(opts, crawler.WithTFT())
+	default:
+		log.Fatalf("Unknown mode %v", *modePtr)
+	}
+	if *challengesPtr {
+		opts = append(opts, crawler.WithChallenges())
+	}
+	if *archivePtr != "" {
+		opts = append(opts, crawler.WithArchive(*archivePtr, *compressionPtr))
+	}
+	client, err := riot.NewClient(*platformPtr, limiter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	EUWCrawler, err = crawler.NewCrawler(
 		// Mandatory Parameters
-		mm, platform, startPlayer, limiter, concurrency,
+		dbm, client, *startPlayerPtr, *concurrencyPtr,
 		// Optional Parameters
-		WithMinNumberOfMatches(*minNumberOfMatchesPtr),
-		WithMinNumberOfPlayers(*minNumberOfPlayersPtr),
+		opts...,
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Stop Crawling gracefully on interrupt, so that pending documents are still written
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Warn("Interrupted, stopping crawler ...")
+		EUWCrawler.Stop()
+	}()
 	// Start Crawling Matches
 	EUWCrawler.Start()
+	// Write the pending batches, whose failures no longer count as crawled, before reporting the result
+	if closer, ok := dbm.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Errorf("Could not close the storage: %v", err)
+		}
+	}
+	if async {
+		log.Infof("Crawled %d matches from %d players", EUWCrawler.NumMatches(), EUWCrawler.NumPlayers())
+	}
 	then := time.Now()
 	fmt.Println("Finished in ", then.Sub(now))
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"go-league-crawler/pkg/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// migrate upgrades the stored documents in place by applying the registered migration steps
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	mongoConfig := addMongoFlags(fs)
+	kindPtr := fs.String("kind", "", "Kind of documents to migrate, e.g. match or player (all kinds if empty)")
+	batchPtr := fs.Int("batch", storage.DefaultMigrationBatchSize, "Number of documents written per bulk write")
+	listPtr := fs.Bool("list", false, "List the registered migration steps instead of applying them")
+	fs.Parse(args)
+
+	kinds := storage.MigrationKinds()
+	if *kindPtr != "" {
+		kinds = []string{*kindPtr}
+	}
+	if *listPtr {
+		for _, k := range kinds {
+			for _, m := range storage.Migrations(k) {
+				log.Info(m)
+			}
+		}
+		return nil
+	}
+
+	mm, err := mongoConfig.manager()
+	if err != nil {
+		return err
+	}
+	defer mm.Close()
+	// Init would migrate every kind and create the unique indexes, which fail on legacy documents of the kinds not migrated
+	if err := mm.Connect(); err != nil {
+		return err
+	}
+	for _, k := range kinds {
+		n, err := mm.Migrate(k, *batchPtr)
+		log.Infof("Migrated %d documents of kind %v", n, k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"go-league-crawler/pkg/storage"
+	"time"
+)
+
+// mongoFlags holds the command line flags configuring the connection to MongoDB,
+// which are shared by the crawler and its subcommands
+type mongoFlags struct {
+	host             *string
+	dbname           *string
+	matchCollection  *string
+	playerCollection *string
+	uri              *string
+	credentials      *string
+	tlsCA            *string
+	tlsCert          *string
+	tlsKey           *string
+	writeConcern     *string
+	journal          *bool
+	writeTimeout     *time.Duration
+	poolSize         *uint64
+}
+
+// addMongoFlags defines the flags configuring the connection to MongoDB on the given flag set
+func addMongoFlags(fs *flag.FlagSet) *mongoFlags {
+	return &mongoFlags{
+		host:             fs.String("host", host, "Host of the Target DB"),
+		dbname:           fs.String("db", dbname, "Name of the Target DB"),
+		matchCollection:  fs.String("mc", matchCollection, "Collection where to ingest the match data into"),
+		playerCollection: fs.String("pc", playerCollection, "Collection where to ingest the player data into"),
+		uri:              fs.String("mongo-uri", "", "Connection string of the Target DB, e.g. mongodb+srv://cluster.example.net/?replicaSet=rs0 (overrides -host)"),
+		credentials:      fs.String("mongo-credentials", "", "JSON file with the username, password and optionally authSource and authMechanism of the Target DB"),
+		tlsCA:            fs.String("mongo-tls-ca", "", "PEM file with the CA certificates to verify the Target DB with (enables TLS)"),
+		tlsCert:          fs.String("mongo-tls-cert", "", "PEM file with the client certificate (enables TLS)"),
+		tlsKey:           fs.String("mongo-tls-key", "", "PEM file with the key of the client certificate, if not part of -mongo-tls-cert"),
+		writeConcern:     fs.String("mongo-w", "", "Write concern: majority, a number of nodes or a tag set (empty uses the default of the Target DB)"),
+		journal:          fs.Bool("mongo-journal", false, "Require writes to be journaled"),
+		writeTimeout:     fs.Duration("mongo-wtimeout", 0, "Maximum time to wait for the write concern to be satisfied (0 waits indefinitely)"),
+		poolSize:         fs.Uint64("mongo-pool", 0, "Maximum number of connections to each server of the Target DB (0 uses the driver's default)"),
+	}
+}
+
+// options translates the flags into MongoOptions
+func (f *mongoFlags) options() []storage.MongoOption {
+	opts := []storage.MongoOption{}
+	if *f.uri != "" {
+		opts = append(opts, storage.WithURI(*f.uri))
+	}
+	if *f.credentials != "" {
+		opts = append(opts, storage.WithCredentialsFile(*f.credentials))
+	}
+	if *f.tlsCA != "" || *f.tlsCert != "" {
+		opts = append(opts, storage.WithTLS(*f.tlsCA, *f.tlsCert, *f.tlsKey))
+	}
+	if *f.writeConcern != "" || *f.journal || *f.writeTimeout > 0 {
+		opts = append(opts, storage.WithWriteConcern(*f.writeConcern, *f.journal, *f.writeTimeout))
+	}
+	if *f.poolSize > 0 {
+		opts = append(opts, storage.WithMaxPoolSize(*f.poolSize))
+	}
+	return opts
+}
+
+// manager returns a MongoManager configured by the flags and the given additional options. It still needs to be initialized
+func (f *mongoFlags) manager(opts ...storage.MongoOption) (*storage.MongoManager, error) {
+	mm, err := storage.NewMManager(*f.host, *f.dbname, *f.matchCollection, *f.playerCollection, append(f.options(), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	mm.CrawlerVersion = Version
+	return mm, nil
+}
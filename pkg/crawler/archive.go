@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-league-crawler/pkg/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Archive Modes
+const (
+	// ARCHIVE_BOTH keeps the raw api responses next to the typed documents
+	ARCHIVE_BOTH = "both"
+	// ARCHIVE_RAW keeps the raw api responses instead of the typed documents
+	ARCHIVE_RAW = "raw"
+)
+
+// archiveResponse archives the exact body of a match or player response, in case archiving is enabled.
+// The kinds of the responses of a riot.Client equal the kinds of the raw documents.
+// In ARCHIVE_RAW mode the raw document is the only one stored, so the body is kept until the worker stores it by storeRaw
+func (c *Crawler) archiveResponse(kind string, id string, data []byte) {
+	switch c.archive {
+	case "":
+		return
+	case ARCHIVE_RAW:
+		c.responses.Store(kind+"/"+id, data)
+		return
+	}
+	if err := c.insertRaw(kind, id, data); err != nil {
+		log.Errorf("Error archiving %v %v: %v", kind, id, err)
+	}
+}
+
+// storeRaw stores the kept response of a match or player by persist, so that it is retried and added to the dead letters on failure
+func (c *Crawler) storeRaw(ctx context.Context, kind string, id string) error {
+	v, ok := c.responses.LoadAndDelete(kind + "/" + id)
+	if !ok {
+		return fmt.Errorf("No response of %v %v to archive", kind, id)
+	}
+	data := v.([]byte)
+	return c.persist(ctx, kind, id, json.RawMessage(data), func() error { return c.insertRaw(kind, id, data) })
+}
+
+// dropRaw discards the kept response of a match or player that is not going to be stored
+func (c *Crawler) dropRaw(kind string, id string) {
+	c.responses.Delete(kind + "/" + id)
+}
+
+func (c *Crawler) insertRaw(kind string, id string, data []byte) error {
+	doc, err := storage.NewRawDocument(kind, id, c.compression, data)
+	if err != nil {
+		return err
+	}
+	return c.dbm.(storage.Archive).InsertRaw(doc)
+}
+
+// storesTyped reports whether the crawler stores typed documents in addition to the raw ones
+func (c *Crawler) storesTyped() bool {
+	return c.archive != ARCHIVE_RAW
+}
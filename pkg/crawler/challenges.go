@@ -0,0 +1,17 @@
+package crawler
+
+import (
+	"go-league-crawler/pkg/storage"
+	"time"
+)
+
+// CrawlChallenges fetches the current challenge progress of a player and stores it together with the time of the request
+func (c *Crawler) CrawlChallenges(puuid string) error {
+	challenges, err := c.client.GetPlayerChallenges(puuid)
+	if err != nil {
+		return err
+	}
+	challenges.Puuid = puuid
+	challenges.Timestamp = time.Now().UnixNano() / int64(time.Millisecond)
+	return c.dbm.(storage.ChallengeManager).InsertPlayerChallenges(*challenges)
+}
@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MinClashRosterInMatch is the number of roster members of a clash team that need to participate
+// in a match in order to link the match to the team. Rosters change between tournaments,
+// thus a single member playing a clash match does not imply it has been played by the current team
+const MinClashRosterInMatch = 3
+
+// ClashRegistry keeps track of the clash teams discovered so far and their rosters' PUUIDs
+type ClashRegistry struct {
+	mux      sync.Mutex
+	teams    map[string][]string
+	byPlayer map[string][]string
+}
+
+// NewClashRegistry returns an empty ClashRegistry
+func NewClashRegistry() *ClashRegistry {
+	return &ClashRegistry{
+		teams:    make(map[string][]string),
+		byPlayer: make(map[string][]string),
+	}
+}
+
+// IsTeamKnown checks if a team has already been registered
+func (r *ClashRegistry) IsTeamKnown(teamID string) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	_, ok := r.teams[teamID]
+	return ok
+}
+
+// AddTeam registers a team together with the PUUIDs of its roster
+func (r *ClashRegistry) AddTeam(teamID string, roster []string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.teams[teamID] = roster
+	for _, p := range roster {
+		r.byPlayer[p] = append(r.byPlayer[p], teamID)
+	}
+}
+
+// TeamsOf returns the IDs of the registered teams that have at least MinClashRosterInMatch of their roster among the given participants
+func (r *ClashRegistry) TeamsOf(participants []string) []string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	count := map[string]int{}
+	for _, p := range participants {
+		for _, t := range r.byPlayer[p] {
+			count[t]++
+		}
+	}
+	teams := []string{}
+	for t, n := range count {
+		if n >= MinClashRosterInMatch || n == len(r.teams[t]) {
+			teams = append(teams, t)
+		}
+	}
+	return teams
+}
+
+// CrawlClashTeams discovers the clash teams of a summoner, stores every team not known so far
+// and returns the PUUIDs of their rosters
+func (c *Crawler) CrawlClashTeams(summoner *types.Summoner) ([]string, error) {
+	cm := c.dbm.(storage.ClashManager)
+	registrations, err := c.client.GetClashPlayersBySummoner(summoner.Id)
+	if err != nil {
+		return nil, err
+	}
+	roster := []string{}
+	for _, reg := range *registrations {
+		if reg.TeamID == "" || c.clash.IsTeamKnown(reg.TeamID) {
+			continue
+		}
+		team, err := c.client.GetClashTeam(reg.TeamID)
+		if err != nil {
+			log.Errorf("Error fetching clash team %v: %v", reg.TeamID, err)
+			continue
+		}
+		members := []string{}
+		for i, p := range team.Players {
+			member, err := c.client.GetPlayerBySummonerID(p.SummonerID)
+			if err != nil {
+				log.Errorf("Error resolving summoner %v of clash team %v: %v", p.SummonerID, team.ID, err)
+				continue
+			}
+			team.Players[i].Puuid = member.Puuid
+			members = append(members, member.Puuid)
+		}
+		if err := cm.InsertClashTeam(*team); err != nil {
+			log.Errorf("Error storing clash team %v: %v", team.ID, err)
+			continue
+		}
+		c.clash.AddTeam(team.ID, members)
+		log.Infof("New Clash Team %v [%v] with %d players", team.Name, team.Abbreviation, len(members))
+		roster = append(roster, members...)
+	}
+	return roster, nil
+}
+
+// LinkClashMatch links a crawled match to the registered clash teams that played it
+func (c *Crawler) LinkClashMatch(match *types.Match) {
+	cm := c.dbm.(storage.ClashManager)
+	for _, t := range c.clash.TeamsOf(match.MetaData.Participants) {
+		if err := cm.LinkClashMatch(t, match.MetaData.MatchID); err != nil {
+			log.Errorf("Error linking match %v to clash team %v: %v", match.MetaData.MatchID, t, err)
+		}
+	}
+}
+
+// prioritize puts the players at the front of the queue, if the store is a PriorityStore, and returns the participants without them.
+// Otherwise the players are put in front of the participants
+func (c *Crawler) prioritize(players []string, participants []string) []string {
+	ps, ok := c.store.(PriorityStore)
+	if !ok {
+		return append(players, participants...)
+	}
+	prioritized := map[string]bool{}
+	for _, p := range players {
+		if prioritized[p] || c.store.IsPlayerKnown(p) || c.isPending(p) {
+			continue
+		}
+		prioritized[p] = true
+		ps.AddToFrontOfQueue(p)
+	}
+	rest := []string{}
+	for _, p := range participants {
+		if !prioritized[p] {
+			rest = append(rest, p)
+		}
+	}
+	return rest
+}
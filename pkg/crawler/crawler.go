@@ -0,0 +1,463 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-league-crawler/pkg/riot"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Void is a shortcut type for struct{} that is specifically used for maps
+type Void struct{}
+
+// Crawler traverses the matches of a platform, starting at a player and continuing with the participants of the matches found.
+// It requests the api by a riot.Client, keeps track of what it has crawled in a Store and stores its findings in a DBManager
+type Crawler struct {
+	// Mandatory Parameters and internal Variables
+	dbm      storage.DBManager
+	client   *riot.Client
+	platform string
+	// maxWriteAttempts is the number of times a match or player is written before it is added to the dead letters
+	maxWriteAttempts int
+	deadLetters      *DeadLetters
+	startPlayer      string
+	store            Store
+	concurrency      int
+	gate             *Gate
+	game             Game
+	clash            *ClashRegistry
+	challenges       bool
+	archive          string
+	compression      string
+	// responses keeps the bodies of the responses to be archived in ARCHIVE_RAW mode by kind/id
+	responses sync.Map
+	// Hooks and the filtered matches, which are not requested again
+	matchFilter MatchFilter
+	onMatch     MatchHook
+	onPlayer    PlayerHook
+	onError     ErrorHook
+	filtered    sync.Map
+	// requeues counts how often the hooks requeued a player
+	maxRequeues int
+	requeues    sync.Map
+	// asyncWrites makes stored matches and players pending until the storage reports them by WriteSucceeded or WriteFailed
+	asyncWrites bool
+	pending     sync.Map
+	// Channels to control flow of excecution between goroutines
+	playerChan   chan string
+	participants chan []string
+	ready        chan Void
+	quit         chan Void
+	stopOnce     sync.Once
+	// Optional Parameters
+	Queue                         string
+	MinNumberOfMatches            int
+	MinNumberOfPlayers            int
+	TotalNumberOfMatchesPerPlayer int
+	FeaturedGamesInterval         time.Duration
+	PlatformStatusInterval        time.Duration
+	mux                           sync.Mutex
+}
+
+// NewCrawler initializes a Crawler for the platform of the given client. The client is copied, so that its requests wait
+// for the platform during maintenances and its responses can be archived, but it keeps sharing the rate limiter
+func NewCrawler(dbm storage.DBManager, client *riot.Client, startPlayer string, concurrency int, options ...Option) (*Crawler, error) {
+	nCrwl := Crawler{
+		// Mandatory Parameters and internal Variables
+		dbm:              dbm,
+		platform:         client.Platform(),
+		maxWriteAttempts: DefaultMaxWriteAttempts,
+		maxRequeues:      DefaultMaxRequeues,
+		startPlayer:      startPlayer,
+		store:            NewMemoryStore(),
+		concurrency:      concurrency,
+		gate:             NewGate(),
+		quit:             make(chan Void),
+		// Optional Parameters
+		Queue:              riot.RANKED,
+		MinNumberOfMatches: DefaultTotalNumberOfMatches,
+		MinNumberOfPlayers: DefaultTotalNumberOfPlayers,
+		// TODO:
+		// TotalNumberOfMatchesPerPlayer:
+	}
+	var err error
+	nCrwl.client, err = client.With(riot.WithGate(nCrwl.gate), riot.WithResponseHook(nCrwl.archiveResponse))
+	if err != nil {
+		return &nCrwl, err
+	}
+
+	nCrwl.game = &LoL{&nCrwl}
+
+	for _, opt := range options {
+		if err := opt(&nCrwl); err != nil {
+			return &nCrwl, err
+		}
+	}
+	if _, ok := nCrwl.game.(*LoL); !ok && nCrwl.usesMatchHooks() {
+		return &nCrwl, fmt.Errorf("Match filters and hooks can only be used for League of Legends")
+	}
+
+	return &nCrwl, nil
+}
+
+// Start sets the crawler in motion
+func (c *Crawler) Start() {
+	playerChan := make(chan string)
+	participants := make(chan []string, c.concurrency)
+	ready := make(chan Void)
+	defer close(ready)
+	defer close(playerChan)
+	defer close(participants)
+	var wgWorker sync.WaitGroup
+	var wgDispatcher sync.WaitGroup
+
+	ctxWorker, cancelWorker := context.WithCancel(context.Background())
+	defer cancelWorker()
+
+	ctxDispatcher, cancelDispatcher := context.WithCancel(context.Background())
+	defer cancelDispatcher()
+
+	sp, err := c.game.GetPlayerByName(c.startPlayer)
+	if err != nil {
+		log.Infof("Erronous Start Player given!")
+		return
+	}
+	seeds, err := c.game.Seeds()
+	if err != nil {
+		log.Errorf("Could not retrieve seeds for %v: %v", c.game.Name(), err)
+	}
+	if c.clash != nil {
+		tournaments, err := c.client.GetClashTournaments()
+		if err != nil {
+			log.Errorf("Could not retrieve clash tournaments: %v", err)
+		} else {
+			for _, t := range *tournaments {
+				log.Infof("Clash Tournament %v (%v %v) with %d phases", t.ID, t.NameKey, t.NameKeySecondary, len(t.Schedule))
+			}
+		}
+	}
+	// c.store.AddToQueue(sp.Puuid)
+	// Store participants and Queue next Players to crawl Matches from.
+	// Keep track of the number of matches crawled and terminate everything
+	// when the termination criterion has been met
+
+	go c.CheckTermination(cancelWorker, playerChan)
+
+	go c.QueuePlayers(ctxDispatcher, ctxWorker, participants, playerChan, &wgDispatcher)
+
+	// Pollers feeding the frontier or pausing the workers are bound to the workers' lifetime
+	if c.PlatformStatusInterval > 0 {
+		wgWorker.Add(1)
+		go c.PollPlatformStatus(ctxWorker, &wgWorker)
+	}
+	if c.FeaturedGamesInterval > 0 {
+		wgWorker.Add(1)
+		go c.PollFeaturedGames(ctxWorker, participants, &wgWorker)
+	}
+
+	// spawn worker and do work
+	for i := 0; i < c.concurrency-1; i++ {
+		go c.CrawlPlayer(ctxWorker, i+1, playerChan, ready, participants, &wgWorker)
+		wgWorker.Add(1)
+	}
+
+	playerChan <- sp.Puuid
+	if len(seeds) > 0 {
+		log.Infof("Seeding %d players", len(seeds))
+		participants <- seeds
+	}
+
+	wgWorker.Wait()
+	cancelDispatcher()
+	wgDispatcher.Wait()
+	log.Infof("Finished")
+	if !c.asyncWrites {
+		// Otherwise the count is final only once the storage has been closed
+		log.Infof("Crawled %d matches from %d players", c.NumMatches(), c.NumPlayers())
+	}
+}
+
+func (c *Crawler) Finished() bool {
+	if c.store.NumMatches() >= c.MinNumberOfMatches || c.store.NumPlayers() >= c.MinNumberOfPlayers {
+		return true
+	}
+	return false
+}
+
+func (c *Crawler) CheckTermination(cancelWorker func(), player chan string) {
+	for {
+		if c.Finished() {
+			cancelWorker()
+			return
+		}
+		select {
+		case <-c.quit:
+			cancelWorker()
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Stop makes the crawler finish its current matches and return from Start as if it had crawled enough
+func (c *Crawler) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.quit)
+	})
+}
+
+func (c *Crawler) QueuePlayers(ctxDispatcher context.Context, ctxWorker context.Context, participants <-chan []string, player chan<- string, wgDispatcher *sync.WaitGroup) {
+	wgDispatcher.Add(1)
+OUTER:
+	for {
+		select {
+		case <-ctxDispatcher.Done():
+			log.Info("Dispatcher's Job has Finished")
+			wgDispatcher.Done()
+			return
+		case p := <-participants:
+		INNER_PARTICIPANTS:
+			for _, player := range p {
+				if c.store.IsPlayerKnown(player) || c.isPending(player) {
+					log.Infof("Player %s already known", player)
+					continue INNER_PARTICIPANTS
+				}
+				if player == "" {
+					log.Warnf("Empty Player about to be inserted")
+				}
+				c.store.AddToQueue(player)
+				log.Infof("New Player %s pushed into Queue", player)
+			}
+		INNER_PLAYER:
+			for i := 0; i < (c.concurrency - len(c.playerChan)); i++ {
+				next, err := c.store.NextPlayer()
+				if err != nil {
+					log.Error(err)
+					continue INNER_PLAYER
+				}
+				if next == "" {
+					log.Warnf("Empty Player ID but programm has not continued...")
+				}
+				log.Infof("Next player in line: %s", next)
+				select {
+				case <-ctxWorker.Done():
+					continue OUTER
+				case player <- next:
+				}
+			}
+
+		}
+	}
+}
+
+// CrawlPlayer fetches a matchlist based on the given summoner and places the gameIds into the crawler's match channel
+func (c *Crawler) CrawlPlayer(ctx context.Context, workerID int, playerChan <-chan string, ready chan<- Void, participants chan<- []string, wg *sync.WaitGroup) {
+	log.Printf("Goroutine with WorkerID %v started", workerID)
+OUTER:
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Done()
+			log.Infof("[WorkerID:%v]: Goroutine finished", workerID)
+			return
+		case <-time.After(1 * time.Minute):
+			log.Infof("[WorkerID:%v]: 1 Minute passed without any new player coming in ...", workerID)
+		case player := <-playerChan:
+			log.Infof("[WorkerID:%v]: Received player %v", workerID, player)
+			if player == "" {
+				log.Warnf("Empty Player ID")
+			}
+			if err := c.gate.Wait(ctx); err != nil {
+				continue OUTER
+			}
+			log.Infof("[WorkerID:%v]: Getting Matchlist of player %v ...", workerID, player)
+			ml, err := c.game.GetMatchList(player)
+			if err != nil {
+				log.Infof("[WorkerID:%v]: Error occured for Player %v", workerID, player)
+				continue OUTER
+			}
+			identifiedParticipants := []string{}
+			requeue := false
+			// Clash teams are registered before the matches are crawled, so that the matches can be linked to them
+			var summoner *types.Summoner
+			roster := []string{}
+			if c.clash != nil {
+				// The player is fetched again below if this fails
+				if summoner, err = c.game.GetPlayerByPUUID(player); err != nil {
+					summoner = nil
+				} else if roster, err = c.CrawlClashTeams(summoner); err != nil {
+					log.Errorf("[WorkerID:%v] Error discovering clash teams of player %s: %v", workerID, player, err)
+				}
+			}
+			// Process each match from matchlist
+		INNER:
+			for _, m := range *ml {
+				select {
+				case <-ctx.Done():
+					continue OUTER
+				default:
+					if c.store.MatchExists(m) || c.isPending(m) {
+						log.Infof("[WorkerID:%v]: Match already crawled %v", workerID, m)
+						continue INNER
+					}
+					if _, ok := c.filtered.Load(m); ok {
+						log.Infof("[WorkerID:%v]: Match already filtered %v", workerID, m)
+						continue INNER
+					}
+					if stored, ok := c.storedBefore(m); ok {
+						log.Infof("[WorkerID:%v]: Match already stored by a previous run %v", workerID, m)
+						// Its participants may not have been crawled by the previous run
+						identifiedParticipants = append(identifiedParticipants, stored.GetParticipants()...)
+						continue INNER
+					}
+					if err := c.gate.Wait(ctx); err != nil {
+						continue OUTER
+					}
+					match, err := c.game.GetMatch(m)
+					if err != nil {
+						log.Errorf("[WorkerID:%v] Error fetching match %v: %v", workerID, m, err)
+						c.dropRaw(c.game.MatchKind(), m)
+						c.fetchFailed(ctx, c.game.MatchKind(), m, err)
+						continue INNER
+					}
+					if !c.storesTyped() {
+						if err := c.storeRaw(ctx, c.game.MatchKind(), m); err != nil {
+							log.Errorf("[WorkerID:%v] Error archiving match %v: %v", workerID, m, err)
+							continue INNER
+						}
+					}
+					log.Infof("[WorkerID:%v]: New Match: %v", workerID, match.GetMatchID())
+					if err := c.runMatchHooks(ctx, match); err != nil {
+						log.Infof("[WorkerID:%v]: Not storing match %v: %v", workerID, match.GetMatchID(), err)
+						c.hookFailed(ctx, c.game.MatchKind(), match.GetMatchID(), err)
+						if errors.Is(err, ErrRequeue) {
+							requeue = true
+						} else {
+							c.filtered.Store(match.GetMatchID(), Void{})
+						}
+						// The participants of a match that is not stored are still worth crawling
+						identifiedParticipants = append(identifiedParticipants, match.GetParticipants()...)
+						continue INNER
+					}
+					// Handle Match, which only counts as crawled once it has been stored
+					if c.storesTyped() {
+						err := c.write(ctx, c.game.MatchKind(), match.GetMatchID(), match, func() error { return c.game.InsertMatch(match) })
+						if err != nil {
+							log.Errorf("[WorkerID:%v] Error storing match %v: %v", workerID, match.GetMatchID(), err)
+							continue INNER
+						}
+					}
+					if !c.storesTyped() || !c.asyncWrites {
+						c.store.ConfirmMatch(match.GetMatchID())
+					}
+					log.Infof("[WorkerID:%v][Region: %v][Player: %v]: Total Number of Matches crawled so far: %v", workerID, c.platform, player, c.store.NumMatches())
+					identifiedParticipants = append(identifiedParticipants, match.GetParticipants()...)
+				}
+			}
+			// Finish up current player and get next player to process
+			if summoner == nil {
+				summoner, err = c.game.GetPlayerByPUUID(player)
+				if err != nil {
+					log.Errorf("[WorkerID:%v] Error fetching player %s: %v", workerID, player, err)
+					c.dropRaw(c.game.PlayerKind(), player)
+					c.fetchFailed(ctx, c.game.PlayerKind(), player, err)
+					c.discovered(ctx, participants, identifiedParticipants)
+					continue OUTER
+				}
+			}
+			if !c.storesTyped() {
+				if err := c.storeRaw(ctx, c.game.PlayerKind(), player); err != nil {
+					log.Errorf("[WorkerID:%v] Error archiving player %s: %v", workerID, player, err)
+					c.discovered(ctx, participants, identifiedParticipants)
+					continue OUTER
+				}
+			}
+			if len(roster) > 0 {
+				// Prefer the rosters of clash teams over arbitrary participants
+				identifiedParticipants = c.prioritize(roster, identifiedParticipants)
+			}
+			c.discovered(ctx, participants, identifiedParticipants)
+			store := c.storesTyped()
+			if err := c.runPlayerHook(ctx, summoner); err != nil {
+				log.Infof("[WorkerID:%v]: Not storing player %v: %v", workerID, player, err)
+				c.hookFailed(ctx, c.game.PlayerKind(), player, err)
+				requeue = requeue || errors.Is(err, ErrRequeue)
+				store = false
+			}
+			if requeue && c.requeued(ctx, player) {
+				log.Infof("[WorkerID:%v] Requeueing player %s", workerID, player)
+				c.store.AddToQueue(player)
+				continue OUTER
+			}
+			// A player that has been requeued too often is finished without the matches the hooks requeued
+			c.requeues.Delete(player)
+			if store {
+				err := c.write(ctx, c.game.PlayerKind(), player, summoner, func() error { return c.game.InsertPlayer(*summoner) })
+				if err != nil {
+					log.Errorf("[WorkerID:%v] Error storing player %s: %v", workerID, player, err)
+					continue OUTER
+				}
+			}
+			if c.challenges {
+				if err := c.CrawlChallenges(player); err != nil {
+					log.Errorf("[WorkerID:%v] Error crawling challenges of player %s: %v", workerID, player, err)
+				}
+			}
+			log.Infof("[WorkerID:%v] Finished working on player %s", workerID, player)
+			if !store || !c.asyncWrites {
+				c.store.ConfirmPlayer(player)
+			}
+		}
+	}
+}
+
+// discovered passes the participants found by a worker to the dispatcher, unless the workers have been stopped meanwhile
+func (c *Crawler) discovered(ctx context.Context, participants chan<- []string, players []string) {
+	select {
+	case <-ctx.Done():
+	case participants <- players:
+	}
+}
+
+// storedBefore returns a lol match if it is already stored, if the storage is able to tell,
+// so that matches stored by previous runs are not requested again
+func (c *Crawler) storedBefore(matchID string) (*types.Match, bool) {
+	if !storage.Supports(c.dbm, storage.CAPABILITY_READ) || !c.storesTyped() || c.game.Name() != "lol" {
+		return nil, false
+	}
+	reader := c.dbm.(storage.MatchReader)
+	match, err := reader.GetMatch(matchID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrUnsupported) {
+			log.Warnf("Could not look up match %v: %v", matchID, err)
+		}
+		return nil, false
+	}
+	return &match, true
+}
+
+// NumMatches returns the number of matches crawled so far
+func (c *Crawler) NumMatches() int {
+	return c.store.NumMatches()
+}
+
+// NumPlayers returns the number of players crawled so far
+func (c *Crawler) NumPlayers() int {
+	return c.store.NumPlayers()
+}
+
+// Platform returns the platform routing value of the crawler, e.g. EUW1
+func (c *Crawler) Platform() string {
+	return c.platform
+}
+
+// Client returns the riot.Client the crawler requests the api with
+func (c *Crawler) Client() *riot.Client {
+	return c.client
+}
@@ -0,0 +1,257 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-league-crawler/pkg/riot"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMaxWriteAttempts is the number of times the crawler tries to store a match or player before giving up on it
+const DefaultMaxWriteAttempts = 3
+
+// Stages at which a dead letter failed
+const (
+	// STAGE_FETCH dead letters could not be requested from the api
+	STAGE_FETCH = "fetch"
+	// STAGE_STORE dead letters could not be written to the storage
+	STAGE_STORE = "store"
+)
+
+// Types of the errors of dead letters
+const (
+	ERROR_HTTP    = "http"
+	ERROR_NETWORK = "network"
+	ERROR_DECODE  = "decode"
+	ERROR_STORAGE = "storage"
+	ERROR_UNKNOWN = "unknown"
+)
+
+// DeadLetter is a match or player that could not be fetched or stored. StatusCode is the last status code of the api, if any.
+// Doc is the document itself if it has been fetched, so that it can be stored again without requesting it once more.
+// FailedAt is given in unix milliseconds
+type DeadLetter struct {
+	Kind       string          `json:"kind"`
+	ID         string          `json:"id"`
+	Platform   string          `json:"platform"`
+	Stage      string          `json:"stage"`
+	ErrorType  string          `json:"errorType"`
+	Error      string          `json:"error"`
+	StatusCode int             `json:"statusCode,omitempty"`
+	Attempts   int             `json:"attempts"`
+	FailedAt   int64           `json:"failedAt"`
+	Doc        json.RawMessage `json:"doc,omitempty"`
+}
+
+// failed updates the error of a dead letter after another failed attempt
+func (l *DeadLetter) failed(err error, errorType string, statusCode int, attempts int) {
+	l.Error, l.ErrorType, l.StatusCode = err.Error(), errorType, statusCode
+	l.Attempts += attempts
+	l.FailedAt = time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// classifyError returns the type of an error of a request, the last status code and the number of attempts made
+func classifyError(err error) (string, int, int) {
+	var reqErr *riot.RequestError
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &reqErr):
+		return ERROR_HTTP, reqErr.StatusCode, reqErr.Attempts
+	case errors.As(err, &netErr):
+		return ERROR_NETWORK, 0, 1
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ERROR_DECODE, 0, 1
+	default:
+		return ERROR_UNKNOWN, 0, 1
+	}
+}
+
+// DeadLetters keeps dead letters in a JSONL file. It is safe for concurrent use
+type DeadLetters struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewDeadLetters returns DeadLetters kept in the file at path
+func NewDeadLetters(path string) *DeadLetters {
+	return &DeadLetters{path: path}
+}
+
+// Add appends a dead letter to the file
+func (d *DeadLetters) Add(letter DeadLetter) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(letter)
+}
+
+// Load returns the dead letters of the file, which is no error if it does not exist
+func (d *DeadLetters) Load() ([]DeadLetter, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, err := os.Open(d.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	letters := []DeadLetter{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		letter := DeadLetter{}
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return nil, fmt.Errorf("Corrupt dead letter file %v: %v", d.path, err)
+		}
+		letters = append(letters, letter)
+	}
+	return letters, scanner.Err()
+}
+
+// Replace replaces the content of the file with the given dead letters, removing it if there are none
+func (d *DeadLetters) Replace(letters []DeadLetter) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(letters) == 0 {
+		err := os.Remove(d.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(d.path), "."+filepath.Base(d.path)+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, letter := range letters {
+		if err := enc.Encode(letter); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.path)
+}
+
+// persist stores a document by calling write, retrying failed writes with an increasing delay.
+// A document that still could not be stored is added to the dead letters and the last error is returned
+func (c *Crawler) persist(ctx context.Context, kind string, id string, doc interface{}, write func() error) error {
+	var err error
+	attempts := 0
+RETRY:
+	for attempts < c.maxWriteAttempts {
+		attempts++
+		if err = write(); err == nil {
+			return nil
+		}
+		log.Warnf("Error storing %v %v at attempt No. %d: %v", kind, id, attempts, err)
+		if attempts < c.maxWriteAttempts {
+			select {
+			case <-ctx.Done():
+				// Stopping gives up on the remaining attempts, the document is kept as a dead letter
+				break RETRY
+			case <-time.After(time.Second * time.Duration(attempts)):
+			}
+		}
+	}
+	letter := DeadLetter{Kind: kind, ID: id, Platform: c.platform, Stage: STAGE_STORE}
+	letter.failed(err, ERROR_STORAGE, 0, attempts)
+	if doc != nil {
+		letter.Doc, _ = json.Marshal(doc)
+	}
+	c.deadLetter(letter)
+	c.reportError(ctx, kind, id, err)
+	return err
+}
+
+// write stores a match or player by persist. With asynchronous writes it stays pending until the storage reports it,
+// so that it is neither requested again nor counted as crawled meanwhile
+func (c *Crawler) write(ctx context.Context, kind string, id string, doc interface{}, insert func() error) error {
+	if c.asyncWrites {
+		c.pending.Store(id, Void{})
+	}
+	err := c.persist(ctx, kind, id, doc, insert)
+	if err != nil {
+		c.pending.Delete(id)
+	}
+	return err
+}
+
+// isPending reports whether a match or player is being written asynchronously. Match ids and puuids never collide
+func (c *Crawler) isPending(id string) bool {
+	_, ok := c.pending.Load(id)
+	return ok
+}
+
+// fetchFailed adds a match or player that could not be requested to the dead letters
+func (c *Crawler) fetchFailed(ctx context.Context, kind string, id string, err error) {
+	letter := DeadLetter{Kind: kind, ID: id, Platform: c.platform, Stage: STAGE_FETCH}
+	errorType, statusCode, attempts := classifyError(err)
+	letter.failed(err, errorType, statusCode, attempts)
+	c.deadLetter(letter)
+	c.reportError(ctx, kind, id, err)
+}
+
+// WriteSucceeded handles the matches and players a storage has written in the background, e.g. a batch of a bulk writer:
+// they count as crawled from now on
+func (c *Crawler) WriteSucceeded(kind string, ids []string) {
+	for _, id := range ids {
+		switch kind {
+		case c.game.MatchKind():
+			c.store.ConfirmMatch(id)
+		case c.game.PlayerKind():
+			c.store.ConfirmPlayer(id)
+		}
+		c.pending.Delete(id)
+	}
+}
+
+// WriteFailed handles the matches and players a storage failed to write in the background, e.g. a batch of a bulk writer:
+// they are added to the dead letters and no longer count as crawled
+func (c *Crawler) WriteFailed(kind string, ids []string, err error) {
+	log.Errorf("Could not store %d documents of kind %v: %v", len(ids), kind, err)
+	for _, id := range ids {
+		switch kind {
+		case c.game.MatchKind():
+			c.store.RevokeMatch(id)
+		case c.game.PlayerKind():
+			c.store.RevokePlayer(id)
+		}
+		c.pending.Delete(id)
+		letter := DeadLetter{Kind: kind, ID: id, Platform: c.platform, Stage: STAGE_STORE}
+		letter.failed(err, ERROR_STORAGE, 0, 1)
+		c.deadLetter(letter)
+		c.reportError(context.Background(), kind, id, err)
+	}
+}
+
+func (c *Crawler) deadLetter(letter DeadLetter) {
+	if c.deadLetters == nil {
+		log.Errorf("Dropping dead letter %v %v (%v failed): %v", letter.Kind, letter.ID, letter.Stage, letter.Error)
+		return
+	}
+	if err := c.deadLetters.Add(letter); err != nil {
+		log.Errorf("Could not add %v %v to the dead letters: %v", letter.Kind, letter.ID, err)
+	}
+}
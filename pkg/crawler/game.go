@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+)
+
+// CrawledMatch is the game agnostic view the crawl loop has on a match
+type CrawledMatch interface {
+	GetMatchID() string
+	GetParticipants() []string
+}
+
+// Game encapsulates everything game specific about a crawl, i.e. which api resources are requested
+// and where their results are stored. The frontier, the store and the workers are shared among all games:
+// player -> match ids -> match -> participants -> player ...
+type Game interface {
+	// Name identifies the game in the logs
+	Name() string
+	// MatchKind and PlayerKind are the kinds of the game's matches and players, e.g. in the dead letters
+	MatchKind() string
+	PlayerKind() string
+	// Seeds returns players to crawl in addition to the start player
+	Seeds() ([]string, error)
+	GetPlayerByName(name string) (*types.Summoner, error)
+	GetPlayerByPUUID(puuid string) (*types.Summoner, error)
+	GetMatchList(puuid string) (*[]string, error)
+	GetMatch(matchID string) (CrawledMatch, error)
+	InsertMatch(match CrawledMatch) error
+	InsertPlayer(player types.Summoner) error
+}
+
+// LoL crawls League of Legends matches of the crawler's queue
+type LoL struct {
+	c *Crawler
+}
+
+func (g *LoL) Name() string {
+	return "lol"
+}
+
+func (g *LoL) MatchKind() string {
+	return storage.KIND_MATCH
+}
+
+func (g *LoL) PlayerKind() string {
+	return storage.KIND_PLAYER
+}
+
+func (g *LoL) Seeds() ([]string, error) {
+	return nil, nil
+}
+
+func (g *LoL) GetPlayerByName(name string) (*types.Summoner, error) {
+	return g.c.client.GetPlayerByName(name)
+}
+
+func (g *LoL) GetPlayerByPUUID(puuid string) (*types.Summoner, error) {
+	return g.c.client.GetPlayerByPUUID(puuid)
+}
+
+func (g *LoL) GetMatchList(puuid string) (*[]string, error) {
+	return g.c.client.GetMatchList(puuid, g.c.Queue)
+}
+
+func (g *LoL) GetMatch(matchID string) (CrawledMatch, error) {
+	match, err := g.c.client.GetMatch(matchID)
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func (g *LoL) InsertMatch(match CrawledMatch) error {
+	m := match.(*types.Match)
+	if err := g.c.dbm.InsertMatch(*m); err != nil {
+		return err
+	}
+	if g.c.clash != nil {
+		// Only stored matches are linked to their clash teams
+		g.c.LinkClashMatch(m)
+	}
+	return nil
+}
+
+func (g *LoL) InsertPlayer(player types.Summoner) error {
+	return g.c.dbm.InsertPlayer(player)
+}
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrSkip and ErrRequeue may be returned, also wrapped, by a MatchHook or PlayerHook. Any other error of a hook is handled like ErrSkip
+// but also passed to the ErrorHook
+var (
+	// ErrSkip makes the crawler skip storing the match or player
+	ErrSkip = errors.New("Skipped by hook")
+	// ErrRequeue makes the crawler skip storing the match or player and put the player back into the queue,
+	// so that the player and their matches not stored so far are crawled again later
+	ErrRequeue = errors.New("Requeued by hook")
+	// ErrTooManyRequeues is passed to the ErrorHook for a player that has been requeued too often and is given up on
+	ErrTooManyRequeues = errors.New("Requeued too often")
+)
+
+// DefaultMaxRequeues is the number of times a player may be requeued by hooks before the crawler gives up on them
+const DefaultMaxRequeues = 3
+
+// MatchFilter reports whether a lol match is to be stored. Matches it rejects are not stored, not counted and not requested again
+type MatchFilter func(match *types.Match) bool
+
+// MatchHook is called for every lol match that passed the MatchFilter before it is stored and may modify it, e.g. to enrich it
+type MatchHook func(ctx context.Context, match *types.Match) error
+
+// PlayerHook is called for every crawled player before it is stored and may modify it
+type PlayerHook func(ctx context.Context, player *types.Summoner) error
+
+// ErrorHook is called whenever a match or player of the given kind could not be fetched or stored or its hook failed
+type ErrorHook func(ctx context.Context, kind string, id string, err error)
+
+// usesMatchHooks reports whether a MatchFilter or MatchHook has been given
+func (c *Crawler) usesMatchHooks() bool {
+	return c.matchFilter != nil || c.onMatch != nil
+}
+
+// runMatchHooks runs the MatchFilter and the MatchHook of a match. It returns ErrSkip if the match has been filtered
+func (c *Crawler) runMatchHooks(ctx context.Context, match CrawledMatch) error {
+	m, ok := match.(*types.Match)
+	if !ok {
+		return nil
+	}
+	if c.matchFilter != nil && !c.matchFilter(m) {
+		return ErrSkip
+	}
+	if c.onMatch != nil {
+		return c.onMatch(ctx, m)
+	}
+	return nil
+}
+
+// runPlayerHook runs the PlayerHook of a player, if any
+func (c *Crawler) runPlayerHook(ctx context.Context, player *types.Summoner) error {
+	if c.onPlayer == nil {
+		return nil
+	}
+	return c.onPlayer(ctx, player)
+}
+
+// hookFailed passes the error of a hook to the ErrorHook unless the hook asked to skip or requeue on purpose
+func (c *Crawler) hookFailed(ctx context.Context, kind string, id string, err error) {
+	if errors.Is(err, ErrSkip) || errors.Is(err, ErrRequeue) {
+		return
+	}
+	log.Errorf("Hook failed for %v %v: %v", kind, id, err)
+	c.reportError(ctx, kind, id, err)
+}
+
+// requeued counts a requeue of a player and reports whether they may be put back into the queue once more.
+// A player that has been requeued too often is reported to the ErrorHook
+func (c *Crawler) requeued(ctx context.Context, player string) bool {
+	requeues := 1
+	if n, ok := c.requeues.Load(player); ok {
+		requeues += n.(int)
+	}
+	if requeues > c.maxRequeues {
+		c.requeues.Delete(player)
+		log.Warnf("Giving up on player %v after %d requeues", player, c.maxRequeues)
+		c.reportError(ctx, c.game.PlayerKind(), player, fmt.Errorf("%w (%d times)", ErrTooManyRequeues, c.maxRequeues))
+		return false
+	}
+	c.requeues.Store(player, requeues)
+	return true
+}
+
+// reportError passes an error of a match or player to the ErrorHook, if any
+func (c *Crawler) reportError(ctx context.Context, kind string, id string, err error) {
+	if c.onError != nil {
+		c.onError(ctx, kind, id, err)
+	}
+}
@@ -0,0 +1,225 @@
+package crawler
+
+import (
+	"fmt"
+	"go-league-crawler/pkg/riot"
+	"go-league-crawler/pkg/storage"
+	"math"
+	"time"
+)
+
+// Default Values
+const (
+	// Allowed Values
+	MaxAllowedTotalNumberOfMatches = 10000000
+	MaxAllowedTotalNumberOfPlayers = 10000000
+	// Default Values
+	DefaultTotalNumberOfMatches          = 10000
+	DefaultTotalNumberOfPlayers          = 10
+	DefaultTotalNumberOfMatchesPerPlayer = math.MaxInt64
+)
+
+type Option func(*Crawler) error
+
+func WithMinNumberOfMatches(numberOfMatches int) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if numberOfMatches > MaxAllowedTotalNumberOfMatches {
+			return fmt.Errorf("Number of Matches exceeds allowed limit (%v)\n", MaxAllowedTotalNumberOfMatches)
+		}
+		if numberOfMatches > 0 {
+			c.MinNumberOfMatches = numberOfMatches
+		}
+		return nil
+	}
+}
+
+func WithMinNumberOfPlayers(numberOfPlayers int) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if numberOfPlayers > MaxAllowedTotalNumberOfPlayers {
+			return fmt.Errorf("Number of Players exceeds allowed limit (%v)\n", MaxAllowedTotalNumberOfPlayers)
+		}
+		if numberOfPlayers > 0 {
+			c.MinNumberOfPlayers = numberOfPlayers
+		}
+		return nil
+	}
+}
+
+func WithQueue(queue string) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if queue == "" {
+			return fmt.Errorf("Queue must not be empty\n")
+		}
+		c.Queue = queue
+		return nil
+	}
+}
+
+// WithTFT makes the crawler crawl Teamfight Tactics matches instead of League of Legends matches
+func WithTFT() func(*Crawler) error {
+	return func(c *Crawler) error {
+		if !storage.Supports(c.dbm, storage.CAPABILITY_TFT) {
+			return fmt.Errorf("DBManager %T is not able to store tft matches\n", c.dbm)
+		}
+		if c.clash != nil {
+			return fmt.Errorf("Clash can only be crawled for League of Legends\n")
+		}
+		c.game = &TFT{c, c.dbm.(storage.TFTManager)}
+		return nil
+	}
+}
+
+// WithClash makes the crawler discover clash teams and crawl the clash matches of their rosters
+func WithClash() func(*Crawler) error {
+	return func(c *Crawler) error {
+		if !storage.Supports(c.dbm, storage.CAPABILITY_CLASH) {
+			return fmt.Errorf("DBManager %T is not able to store clash teams\n", c.dbm)
+		}
+		if _, ok := c.game.(*LoL); !ok {
+			return fmt.Errorf("Clash can only be crawled for League of Legends\n")
+		}
+		c.Queue = riot.CLASH
+		c.clash = NewClashRegistry()
+		return nil
+	}
+}
+
+// WithChallenges makes the crawler fetch and store the challenge progress of every crawled player
+func WithChallenges() func(*Crawler) error {
+	return func(c *Crawler) error {
+		if !storage.Supports(c.dbm, storage.CAPABILITY_CHALLENGES) {
+			return fmt.Errorf("DBManager %T is not able to store challenges\n", c.dbm)
+		}
+		if _, ok := c.game.(*LoL); !ok {
+			return fmt.Errorf("Challenges can only be crawled for League of Legends\n")
+		}
+		c.challenges = true
+		return nil
+	}
+}
+
+// WithArchive makes the crawler keep the exact api responses of matches and players, compressed by the given method.
+// The raw documents are stored next to (ARCHIVE_BOTH) or instead of (ARCHIVE_RAW) the typed documents
+func WithArchive(mode string, compression string) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if mode != ARCHIVE_BOTH && mode != ARCHIVE_RAW {
+			return fmt.Errorf("Unknown archive mode %v\n", mode)
+		}
+		if compression != storage.GZIP && compression != storage.ZSTD {
+			return fmt.Errorf("Unknown compression %v\n", compression)
+		}
+		if !storage.Supports(c.dbm, storage.CAPABILITY_ARCHIVE) {
+			return fmt.Errorf("DBManager %T is not able to archive raw documents\n", c.dbm)
+		}
+		c.archive = mode
+		c.compression = compression
+		return nil
+	}
+}
+
+// WithDeadLetters makes the crawler append the matches and players it could not store to the file at path
+func WithDeadLetters(path string) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if path == "" {
+			return fmt.Errorf("Dead letter file must not be empty\n")
+		}
+		c.deadLetters = NewDeadLetters(path)
+		return nil
+	}
+}
+
+// WithAsyncWrites makes the crawler count its matches and players as crawled only once the storage reports them as written
+// by WriteSucceeded, e.g. when it writes in bulks in the background. Failed writes are to be reported by WriteFailed.
+// The number of crawled matches and players is final once the storage has been closed
+func WithAsyncWrites() func(*Crawler) error {
+	return func(c *Crawler) error {
+		c.asyncWrites = true
+		return nil
+	}
+}
+
+// WithStore makes the crawler keep track of the crawled matches and players and of its queue in the given Store
+func WithStore(store Store) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if store == nil {
+			return fmt.Errorf("Store must not be nil\n")
+		}
+		c.store = store
+		return nil
+	}
+}
+
+// WithMatchFilter makes the crawler store and count only the lol matches accepted by filter, e.g. the matches of a champion
+func WithMatchFilter(filter MatchFilter) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if filter == nil {
+			return fmt.Errorf("Match filter must not be nil\n")
+		}
+		c.matchFilter = filter
+		return nil
+	}
+}
+
+// WithOnMatch makes the crawler call hook for every lol match before storing it
+func WithOnMatch(hook MatchHook) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if hook == nil {
+			return fmt.Errorf("Match hook must not be nil\n")
+		}
+		c.onMatch = hook
+		return nil
+	}
+}
+
+// WithOnPlayer makes the crawler call hook for every player before storing it
+func WithOnPlayer(hook PlayerHook) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if hook == nil {
+			return fmt.Errorf("Player hook must not be nil\n")
+		}
+		c.onPlayer = hook
+		return nil
+	}
+}
+
+// WithMaxRequeues makes the crawler give up on a player once the hooks requeued them maxRequeues times
+func WithMaxRequeues(maxRequeues int) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if maxRequeues < 0 {
+			return fmt.Errorf("Maximum number of requeues must not be negative\n")
+		}
+		c.maxRequeues = maxRequeues
+		return nil
+	}
+}
+
+// WithOnError makes the crawler call hook for every match or player that could not be fetched or stored or whose hook failed
+func WithOnError(hook ErrorHook) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if hook == nil {
+			return fmt.Errorf("Error hook must not be nil\n")
+		}
+		c.onError = hook
+		return nil
+	}
+}
+
+func WithFeaturedGamesInterval(interval time.Duration) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if interval < 0 {
+			return fmt.Errorf("Featured Games Interval must not be negative (%v)\n", interval)
+		}
+		c.FeaturedGamesInterval = interval
+		return nil
+	}
+}
+
+func WithPlatformStatusInterval(interval time.Duration) func(*Crawler) error {
+	return func(c *Crawler) error {
+		if interval < 0 {
+			return fmt.Errorf("Platform Status Interval must not be negative (%v)\n", interval)
+		}
+		c.PlatformStatusInterval = interval
+		return nil
+	}
+}
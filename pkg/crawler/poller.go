@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"context"
+	types "go-league-crawler/pkg/types/lol"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Gate pauses the workers of a crawler while its platform is unavailable, e.g. during maintenances
+type Gate struct {
+	mux    sync.Mutex
+	open   chan Void
+	reason string
+}
+
+// NewGate returns an opened Gate
+func NewGate() *Gate {
+	open := make(chan Void)
+	close(open)
+	return &Gate{
+		open: open,
+	}
+}
+
+// Pause closes the gate until Resume is called. Calling Pause on a paused gate only updates the reason
+func (g *Gate) Pause(reason string) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.reason == "" {
+		g.open = make(chan Void)
+	}
+	g.reason = reason
+}
+
+// Resume opens the gate and releases every goroutine waiting for it
+func (g *Gate) Resume() {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.reason == "" {
+		return
+	}
+	close(g.open)
+	g.reason = ""
+}
+
+// Paused reports whether the gate is closed and the reason for it
+func (g *Gate) Paused() (bool, string) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	return g.reason != "", g.reason
+}
+
+// Wait blocks until the gate is opened or the context is done
+func (g *Gate) Wait(ctx context.Context) error {
+	g.mux.Lock()
+	open := g.open
+	g.mux.Unlock()
+	select {
+	case <-open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PollPlatformStatus periodically checks lol-status-v4 and pauses the workers
+// while a maintenance is in progress or a critical incident is ongoing
+func (c *Crawler) PollPlatformStatus(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		status, err := c.client.GetPlatformStatus()
+		if err != nil {
+			log.Errorf("[Platform: %v] Could not retrieve platform status: %v", c.platform, err)
+		} else if reason := unavailability(status); reason != "" {
+			if paused, _ := c.gate.Paused(); !paused {
+				log.Warnf("[Platform: %v] Pausing crawler: %v", c.platform, reason)
+			}
+			c.gate.Pause(reason)
+		} else if paused, _ := c.gate.Paused(); paused {
+			log.Infof("[Platform: %v] Platform available again, resuming crawler", c.platform)
+			c.gate.Resume()
+		}
+		select {
+		case <-ctx.Done():
+			// Never leave workers behind waiting for a gate nobody is going to open
+			c.gate.Resume()
+			log.Info("Platform Status Poller finished")
+			return
+		case <-time.After(c.PlatformStatusInterval):
+		}
+	}
+}
+
+// unavailability returns the reason why the given platform should not be crawled, if any
+func unavailability(status *types.PlatformData) string {
+	reasons := []string{}
+	for _, m := range status.Maintenances {
+		if m.MaintenanceStatus == types.MaintenanceInProgress {
+			reasons = append(reasons, "maintenance: "+m.Title())
+		}
+	}
+	for _, i := range status.Incidents {
+		if i.IncidentSeverity == types.SeverityCritical {
+			reasons = append(reasons, "incident: "+i.Title())
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// PollFeaturedGames periodically requests the featured games of the crawler's platform
+// and pushes the participants' PUUIDs into the crawl frontier
+func (c *Crawler) PollFeaturedGames(ctx context.Context, participants chan<- []string, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		interval := c.FeaturedGamesInterval
+		featured, err := c.client.GetFeaturedGames()
+		if err != nil {
+			log.Errorf("[Platform: %v] Could not retrieve featured games: %v", c.platform, err)
+		} else {
+			players := []string{}
+			for _, g := range featured.GameList {
+				for _, p := range g.Participants {
+					if p.Bot || p.Puuid == "" {
+						continue
+					}
+					players = append(players, p.Puuid)
+				}
+			}
+			log.Infof("[Platform: %v] Found %d players in %d featured games", c.platform, len(players), len(featured.GameList))
+			if len(players) > 0 {
+				select {
+				case <-ctx.Done():
+				case participants <- players:
+				}
+			}
+			// Riot suggests how long to wait before the featured games change
+			if suggested := time.Duration(featured.ClientRefreshInterval) * time.Second; suggested > interval {
+				interval = suggested
+			}
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("Featured Games Poller finished")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	tft "go-league-crawler/pkg/types/tft"
+)
+
+// Redrive fetches the document of a dead letter unless the dead letter keeps it, and stores it.
+// On failure the dead letter is updated with the new error
+func (c *Crawler) Redrive(letter *DeadLetter) error {
+	var game Game = &LoL{c}
+	if letter.Kind == storage.KIND_TFT_MATCH || letter.Kind == storage.KIND_TFT_PLAYER {
+		if !storage.Supports(c.dbm, storage.CAPABILITY_TFT) {
+			return fmt.Errorf("DBManager %T is not able to store tft matches", c.dbm)
+		}
+		game = &TFT{c, c.dbm.(storage.TFTManager)}
+	}
+	if letter.Kind != game.MatchKind() && letter.Kind != game.PlayerKind() {
+		return fmt.Errorf("Unknown kind %v", letter.Kind)
+	}
+
+	var doc interface{}
+	if len(letter.Doc) > 0 {
+		switch letter.Kind {
+		case storage.KIND_MATCH:
+			doc = &types.Match{}
+		case storage.KIND_TFT_MATCH:
+			doc = &tft.Match{}
+		default:
+			doc = &types.Summoner{}
+		}
+		if err := json.Unmarshal(letter.Doc, doc); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if letter.Kind == game.MatchKind() {
+			doc, err = game.GetMatch(letter.ID)
+		} else {
+			doc, err = game.GetPlayerByPUUID(letter.ID)
+		}
+		if err != nil {
+			letter.Stage = STAGE_FETCH
+			errorType, statusCode, attempts := classifyError(err)
+			letter.failed(err, errorType, statusCode, attempts)
+			return err
+		}
+		letter.Doc, _ = json.Marshal(doc)
+	}
+
+	var err error
+	if letter.Kind == game.MatchKind() {
+		err = game.InsertMatch(doc.(CrawledMatch))
+	} else {
+		err = game.InsertPlayer(*doc.(*types.Summoner))
+	}
+	if err != nil {
+		letter.Stage = STAGE_STORE
+		letter.failed(err, ERROR_STORAGE, 0, 1)
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type CacheType struct {
+	mux   sync.RWMutex
+	Cache map[string]struct{}
+}
+
+func NewCache() *CacheType {
+	return &CacheType{
+		Cache: make(map[string]struct{}),
+	}
+}
+
+func (c *CacheType) String() string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	keys := []string{}
+	for k, _ := range c.Cache {
+		keys = append(keys, k)
+	}
+	return fmt.Sprintf("%v", keys)
+}
+
+// Store keeps track of the matches and players a crawler has traversed through and of the players it is going to crawl next.
+// Implementations have to be safe for concurrent use, e.g. to share a frontier among several processes
+type Store interface {
+	// ConfirmMatch and ConfirmPlayer mark a match or player as crawled, RevokeMatch and RevokePlayer undo it
+	ConfirmMatch(id string)
+	ConfirmPlayer(id string)
+	RevokeMatch(id string)
+	RevokePlayer(id string)
+	MatchExists(id string) bool
+	IsPlayerKnown(id string) bool
+	NumMatches() int
+	NumPlayers() int
+	// NextPlayer removes the next player from the queue and returns it, or an error if the queue is empty
+	NextPlayer() (string, error)
+	AddToQueue(player string)
+}
+
+// PriorityStore is implemented by Stores that are able to put players at the front of their queue, e.g. the rosters of clash teams
+type PriorityStore interface {
+	AddToFrontOfQueue(player string)
+}
+
+// MemoryStore is the Store of a crawler unless another one is given by WithStore. It caches the IDs in memory
+type MemoryStore struct {
+	Match       *CacheType
+	PlayerKnown *CacheType
+	mux         sync.Mutex
+	PlayerQueue *list.List
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		Match:       NewCache(),
+		PlayerKnown: NewCache(),
+		PlayerQueue: list.New(),
+	}
+}
+
+// ConfirmMatch inserts GameId into Sink
+func (s *MemoryStore) ConfirmMatch(id string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.Match.Cache[id] = struct{}{}
+}
+
+// ConfirmPlayer inserts AccoundId into SInk
+func (s *MemoryStore) ConfirmPlayer(id string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.PlayerKnown.Cache[id] = struct{}{}
+}
+
+// RevokeMatch removes a GameId from the Sink, e.g. after its write failed
+func (s *MemoryStore) RevokeMatch(id string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.Match.Cache, id)
+}
+
+// RevokePlayer removes an AccountId from the Sink, e.g. after its write failed
+func (s *MemoryStore) RevokePlayer(id string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.PlayerKnown.Cache, id)
+}
+
+// MatchExists checks if a match had been inserted to the sink
+func (s *MemoryStore) MatchExists(id string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	_, ok := s.Match.Cache[id]
+	return ok
+}
+
+// IsPlayerKnown checks if a match had been inserted to the sink
+func (s *MemoryStore) IsPlayerKnown(id string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	_, ok := s.PlayerKnown.Cache[id]
+	return ok
+}
+
+// NumMatches returns the number of Matches crawled
+func (s *MemoryStore) NumMatches() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.Match.Cache)
+}
+
+// NumPlayers returns the players of Matches crawled
+func (s *MemoryStore) NumPlayers() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.PlayerKnown.Cache)
+}
+
+// NextPlayer returns the next player in the queue to process
+func (s *MemoryStore) NextPlayer() (string, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.PlayerQueue.Len() == 0 {
+		return "", errors.New("No player to process")
+	}
+	next := s.PlayerQueue.Front()
+	s.PlayerQueue.Remove(next)
+	return next.Value.(string), nil
+}
+
+// AddToQueue inserts a player at the back of the queue of players to process
+func (s *MemoryStore) AddToQueue(player string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.PlayerQueue.PushBack(player)
+}
+
+// AddToFrontOfQueue inserts a player at the front of the queue of players to process
+func (s *MemoryStore) AddToFrontOfQueue(player string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.PlayerQueue.PushFront(player)
+}
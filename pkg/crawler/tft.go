@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	tft "go-league-crawler/pkg/types/tft"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TFT crawls Teamfight Tactics matches, seeding the frontier with the challenger league
+type TFT struct {
+	c  *Crawler
+	tm storage.TFTManager
+}
+
+func (g *TFT) Name() string {
+	return "tft"
+}
+
+func (g *TFT) MatchKind() string {
+	return storage.KIND_TFT_MATCH
+}
+
+func (g *TFT) PlayerKind() string {
+	return storage.KIND_TFT_PLAYER
+}
+
+func (g *TFT) Seeds() ([]string, error) {
+	league, err := g.c.client.GetTFTChallengerLeague()
+	if err != nil {
+		return nil, err
+	}
+	seeds := []string{}
+	for _, e := range league.Entries {
+		if e.Puuid != "" {
+			seeds = append(seeds, e.Puuid)
+			continue
+		}
+		summoner, err := g.c.client.GetTFTPlayerBySummonerID(e.SummonerID)
+		if err != nil {
+			log.Errorf("Error resolving tft summoner %v: %v", e.SummonerID, err)
+			continue
+		}
+		seeds = append(seeds, summoner.Puuid)
+	}
+	return seeds, nil
+}
+
+func (g *TFT) GetPlayerByName(name string) (*types.Summoner, error) {
+	return g.c.client.GetTFTPlayerByName(name)
+}
+
+func (g *TFT) GetPlayerByPUUID(puuid string) (*types.Summoner, error) {
+	return g.c.client.GetTFTPlayerByPUUID(puuid)
+}
+
+func (g *TFT) GetMatchList(puuid string) (*[]string, error) {
+	return g.c.client.GetTFTMatchList(puuid)
+}
+
+func (g *TFT) GetMatch(matchID string) (CrawledMatch, error) {
+	match, err := g.c.client.GetTFTMatch(matchID)
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func (g *TFT) InsertMatch(match CrawledMatch) error {
+	return g.tm.InsertTFTMatch(*match.(*tft.Match))
+}
+
+func (g *TFT) InsertPlayer(player types.Summoner) error {
+	return g.tm.InsertTFTPlayer(player)
+}
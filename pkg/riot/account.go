@@ -0,0 +1,23 @@
+package riot
+
+import types "go-league-crawler/pkg/types/lol"
+
+// GetAccountByPUUID retrieves the riot id of a player
+func (c *Client) GetAccountByPUUID(puuid string) (*types.Account, error) {
+	AccountDTO := types.Account{}
+	url := c.url(accountRegion(c.region), ACCOUNT_ROOT, ACCOUNTS+"by-puuid/"+puuid)
+	if err := c.get(url, &AccountDTO); err != nil {
+		return nil, err
+	}
+	return &AccountDTO, nil
+}
+
+// GetAccountByRiotID retrieves the account of a riot id given by its game name and tag line, e.g. dwaynehart and EUW
+func (c *Client) GetAccountByRiotID(gameName string, tagLine string) (*types.Account, error) {
+	AccountDTO := types.Account{}
+	url := c.url(accountRegion(c.region), ACCOUNT_ROOT, ACCOUNTS+"by-riot-id/"+escape(gameName)+"/"+escape(tagLine))
+	if err := c.get(url, &AccountDTO); err != nil {
+		return nil, err
+	}
+	return &AccountDTO, nil
+}
@@ -0,0 +1,13 @@
+package riot
+
+import types "go-league-crawler/pkg/types/lol"
+
+// GetPlayerChallenges retrieves the challenge progress and percentiles of a player
+func (c *Client) GetPlayerChallenges(puuid string) (*types.PlayerChallenges, error) {
+	PlayerInfoDTO := types.PlayerChallenges{}
+	url := c.url(c.platform, ROOT, CHALLENGES_PLAYER_DATA+puuid)
+	if err := c.get(url, &PlayerInfoDTO); err != nil {
+		return nil, err
+	}
+	return &PlayerInfoDTO, nil
+}
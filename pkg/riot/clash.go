@@ -0,0 +1,46 @@
+package riot
+
+import (
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+)
+
+// GetClashTournaments retrieves the active and upcoming clash tournaments
+func (c *Client) GetClashTournaments() (*[]types.ClashTournament, error) {
+	tournaments := []types.ClashTournament{}
+	url := c.url(c.platform, ROOT, CLASH_TOURNAMENTS)
+	if err := c.get(url, &tournaments); err != nil {
+		return nil, err
+	}
+	return &tournaments, nil
+}
+
+// GetClashTournament retrieves a clash tournament based on its id
+func (c *Client) GetClashTournament(tournamentID int) (*types.ClashTournament, error) {
+	TournamentDTO := types.ClashTournament{}
+	url := c.url(c.platform, ROOT, CLASH_TOURNAMENTS+fmt.Sprintf("/%d", tournamentID))
+	if err := c.get(url, &TournamentDTO); err != nil {
+		return nil, err
+	}
+	return &TournamentDTO, nil
+}
+
+// GetClashPlayersBySummoner retrieves the clash registrations of a summoner
+func (c *Client) GetClashPlayersBySummoner(summonerID string) (*[]types.ClashPlayer, error) {
+	players := []types.ClashPlayer{}
+	url := c.url(c.platform, ROOT, CLASH_PLAYERS_BY_SUMMONER+summonerID)
+	if err := c.get(url, &players); err != nil {
+		return nil, err
+	}
+	return &players, nil
+}
+
+// GetClashTeam retrieves a clash team based on its id
+func (c *Client) GetClashTeam(teamID string) (*types.ClashTeam, error) {
+	TeamDTO := types.ClashTeam{}
+	url := c.url(c.platform, ROOT, CLASH_TEAMS+teamID)
+	if err := c.get(url, &TeamDTO); err != nil {
+		return nil, err
+	}
+	return &TeamDTO, nil
+}
@@ -0,0 +1,265 @@
+package riot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// ROOT refers to the root of the lol api resources
+	ROOT = ".api.riotgames.com/lol/"
+
+	// ACCOUNT_ROOT refers to the root of the api resources shared by all riot games, e.g. accounts
+	ACCOUNT_ROOT = ".api.riotgames.com/riot/"
+
+	// DefaultMaxAttempts is the number of times a resource is requested before giving up on it
+	DefaultMaxAttempts = 10
+)
+
+// Kinds of the responses passed to a ResponseHook. They equal the kinds of the raw documents of pkg/storage
+const (
+	KIND_MATCH      = "match"
+	KIND_PLAYER     = "player"
+	KIND_TFT_MATCH  = "tft-match"
+	KIND_TFT_PLAYER = "tft-player"
+)
+
+// RequestError encapsulates the Status Code from the HTTP Request, in case in was not 200,
+// and the number of Attempts made to request the resource
+type RequestError struct {
+	err        error
+	StatusCode int
+	Attempts   int
+}
+
+func (e *RequestError) Error() string {
+	return e.err.Error()
+}
+
+// Gate lets requests wait for an unavailable platform to recover instead of using up their attempts, see WithGate
+type Gate interface {
+	// Paused reports whether the platform is unavailable and the reason for it
+	Paused() (bool, string)
+	// Wait blocks until the platform is available again or the context is done
+	Wait(ctx context.Context) error
+}
+
+// ResponseHook is given the exact body of every match and player response before it is decoded, e.g. to archive it
+type ResponseHook func(kind string, id string, data []byte)
+
+// Client requests the riot api of a single platform. In essence it consists of the platform, its region, an api key,
+// an http client and a rate limiter, which may be shared among several clients. It is safe for concurrent use
+type Client struct {
+	platform    string
+	region      string
+	apiKey      string
+	baseURL     string
+	maxAttempts int
+	client      *http.Client
+	ratelimit   *rate.Limiter
+	gate        Gate
+	onResponse  ResponseHook
+}
+
+// NewClient returns a Client for the platform of a region marker, e.g. EUW, whose requests are limited by rl.
+// The api key is read from the environment variable DEV_KEY unless it is given by WithAPIKey
+func NewClient(region string, rl *rate.Limiter, options ...Option) (*Client, error) {
+	if rl == nil {
+		return nil, fmt.Errorf("Rate limiter must not be nil")
+	}
+	platform, regional, err := routing(region)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		platform:    platform,
+		region:      regional,
+		apiKey:      os.Getenv("DEV_KEY"),
+		maxAttempts: DefaultMaxAttempts,
+		client:      &http.Client{},
+		ratelimit:   rl,
+	}
+	return c.With(options...)
+}
+
+// With returns a copy of the client with the given options applied. The copy shares the rate limiter of the client
+func (c *Client) With(options ...Option) (*Client, error) {
+	nc := *c
+	for _, opt := range options {
+		if err := opt(&nc); err != nil {
+			return &nc, err
+		}
+	}
+	return &nc, nil
+}
+
+// Platform returns the platform routing value of the client, e.g. EUW1
+func (c *Client) Platform() string {
+	return c.platform
+}
+
+// Region returns the regional routing value of the client, e.g. europe
+func (c *Client) Region() string {
+	return c.region
+}
+
+// url returns the url of a resource below the root of an api, e.g. ROOT, on the host of a routing value
+func (c *Client) url(routing string, root string, resource string) string {
+	if c.baseURL != "" {
+		return c.baseURL + root[strings.Index(root, "/"):] + resource
+	}
+	return "https://" + routing + root + resource
+}
+
+// escape escapes a user given part of a resource, e.g. a summoner name or a riot id
+func escape(s string) string {
+	return url.PathEscape(s)
+}
+
+// IsNotFound reports whether a request failed because the resource does not exist,
+// e.g. a player that is not in a game or a riot id nobody has
+func IsNotFound(err error) bool {
+	var reqErr *RequestError
+	return errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound
+}
+
+// _sendRequest represents a proxy function that includes the Rate Limit Management
+// before requesting the ressource. It will be invoked by the function SendRequest
+func (c *Client) _sendRequest(req *http.Request) (*http.Response, error) {
+	if !c.ratelimit.Allow() {
+		r := c.ratelimit.Reserve()
+		log.Warnf("Rate Limit has been reached, need to wait for %v", r.Delay())
+		time.Sleep(r.Delay())
+	}
+	return c.client.Do(req)
+}
+
+// SendRequest attempts (at most maxAttempts times) to get the http contents from the given url
+// automatically re-attempts to request the url based on the settings of the Client in case of internal server issues or rate limit exceedances
+// in case of neither: returns an error with the status code
+func (c *Client) SendRequest(url string, maxAttempts ...int) (*http.Response, error) {
+	return c.sendRequest(url, true, maxAttempts...)
+}
+
+// sendRequest implements SendRequest. Requests that are gated do not use up their attempts
+// on temporary errors while the platform is known to be unavailable, but wait for it to recover
+func (c *Client) sendRequest(url string, gated bool, maxAttempts ...int) (*http.Response, error) {
+	maxAtt := c.maxAttempts
+	if len(maxAttempts) > 0 && maxAttempts[0] != 0 {
+		maxAtt = maxAttempts[0]
+	}
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Add("X-Riot-Token", c.apiKey)
+	lastStatus := 0
+	for i := 1; i <= maxAtt; i++ {
+		response, e := c._sendRequest(req)
+		if e != nil {
+			return nil, e
+		}
+		// log.Infof("Status Code: %v", response.StatusCode)
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+		}
+		lastStatus = response.StatusCode
+		switch response.StatusCode {
+		case http.StatusOK: // 200
+			return response, nil
+		case http.StatusTooManyRequests: // 429
+			log.Warnf("Temporary Error: %v for url %v", http.StatusText(response.StatusCode), url)
+			// see: https://stackoverflow.com/questions/17573190/how-to-multiply-duration-by-integer
+			time.Sleep(time.Second * time.Duration(10*i))
+		case http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusGatewayTimeout: // 500, 503, 504:
+			if c.gate != nil && gated {
+				if paused, reason := c.gate.Paused(); paused {
+					log.Warnf("Platform %v unavailable (%v), waiting for it to recover before requesting url %v", c.platform, reason, url)
+					c.gate.Wait(context.Background())
+					i--
+					continue
+				}
+			}
+			log.Warnf("Temporary Error: %v occured at attempt No. %d for url %v", http.StatusText(response.StatusCode), i, url)
+			// see: https://stackoverflow.com/questions/17573190/how-to-multiply-duration-by-integer
+			time.Sleep(time.Second * time.Duration(10*i))
+			continue
+		default:
+			log.Errorf("Non-temporary Error: %v for url %v", http.StatusText(response.StatusCode), url)
+			err := &RequestError{
+				fmt.Errorf("Non-temporary Error: %s", http.StatusText(response.StatusCode)),
+				response.StatusCode,
+				i,
+			}
+			return nil, err
+		}
+	}
+	log.Errorf("Maximum Attempts (%d) reached. Could not retrieve url %v. Continuing...", maxAtt, url)
+	err := &RequestError{
+		errors.New("Maximum Attempts Exceeded"),
+		lastStatus,
+		maxAtt,
+	}
+	return nil, err
+}
+
+// get requests a url and decodes the body of the response into the given DTO
+func (c *Client) get(url string, dto interface{}) error {
+	log.Infof("Request URL: %v", url)
+	response, err := c.SendRequest(url, c.maxAttempts)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return json.NewDecoder(response.Body).Decode(dto)
+}
+
+// getDocument requests a match or player and decodes the body of the response into the given DTO.
+// In case a ResponseHook is set, it is given the exact bytes of the body beforehand
+func (c *Client) getDocument(url string, kind string, id string, dto interface{}) error {
+	log.Infof("Request URL: %v", url)
+	response, err := c.SendRequest(url, c.maxAttempts)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if c.onResponse != nil {
+		c.onResponse(kind, id, data)
+	}
+	return json.Unmarshal(data, dto)
+}
+
+// getPlayerDocument requests a player by another key than their puuid, e.g. their name, and decodes the body of the response.
+// In case a ResponseHook is set, it is given the body under the puuid of the player, like the responses of players requested by puuid
+func (c *Client) getPlayerDocument(url string, kind string, dto *types.Summoner) error {
+	log.Infof("Request URL: %v", url)
+	response, err := c.SendRequest(url, c.maxAttempts)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dto); err != nil {
+		return err
+	}
+	if c.onResponse != nil {
+		c.onResponse(kind, dto.Puuid, data)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package riot
+
+import types "go-league-crawler/pkg/types/lol"
+
+// GetLeagueEntries retrieves the ranks of a player in every ranked queue they played
+func (c *Client) GetLeagueEntries(puuid string) (*[]types.LeagueEntry, error) {
+	entries := []types.LeagueEntry{}
+	url := c.url(c.platform, ROOT, LEAGUE_ENTRIES_BY_PUUID+puuid)
+	if err := c.get(url, &entries); err != nil {
+		return nil, err
+	}
+	return &entries, nil
+}
+
+// GetLeagueEntriesBySummonerID retrieves the ranks of a player based on a given summonerId
+func (c *Client) GetLeagueEntriesBySummonerID(summonerID string) (*[]types.LeagueEntry, error) {
+	entries := []types.LeagueEntry{}
+	url := c.url(c.platform, ROOT, LEAGUE_ENTRIES_BY_SUMMONER+summonerID)
+	if err := c.get(url, &entries); err != nil {
+		return nil, err
+	}
+	return &entries, nil
+}
+
+// GetChallengerLeague retrieves the challenger league of a ranked queue, e.g. types.QueueRankedSolo
+func (c *Client) GetChallengerLeague(queue string) (*types.LeagueList, error) {
+	return c.getLeague(CHALLENGER_LEAGUES, queue)
+}
+
+// GetGrandmasterLeague retrieves the grandmaster league of a ranked queue
+func (c *Client) GetGrandmasterLeague(queue string) (*types.LeagueList, error) {
+	return c.getLeague(GRANDMASTER_LEAGUES, queue)
+}
+
+// GetMasterLeague retrieves the master league of a ranked queue
+func (c *Client) GetMasterLeague(queue string) (*types.LeagueList, error) {
+	return c.getLeague(MASTER_LEAGUES, queue)
+}
+
+func (c *Client) getLeague(resource string, queue string) (*types.LeagueList, error) {
+	LeagueDTO := types.LeagueList{}
+	url := c.url(c.platform, ROOT, resource+queue)
+	if err := c.get(url, &LeagueDTO); err != nil {
+		return nil, err
+	}
+	return &LeagueDTO, nil
+}
@@ -0,0 +1,58 @@
+package riot
+
+const (
+	// SUMMONERS refers to the api resource for fetching information about players
+	SUMMONERS = "summoner/v4/summoners/"
+
+	// ACCOUNTS refers to the api resource for fetching the riot id of players and vice versa
+	ACCOUNTS = "account/v1/accounts/"
+
+	// MATCHLIST_BY_PUUID refers to the api resource for fetching a player's match history
+	MATCHLIST_BY_PUUID = "match/v5/matches/by-puuid/"
+
+	// MATCH refers to the api resource for fetching a match dto, which is followed by TIMELINE for fetching its timeline
+	MATCH    = "match/v5/matches/"
+	TIMELINE = "/timeline"
+
+	// LEAGUE_ENTRIES_BY_PUUID and LEAGUE_ENTRIES_BY_SUMMONER refer to the api resources for fetching the ranks of a player
+	LEAGUE_ENTRIES_BY_PUUID    = "league/v4/entries/by-puuid/"
+	LEAGUE_ENTRIES_BY_SUMMONER = "league/v4/entries/by-summoner/"
+
+	// CHALLENGER_LEAGUES, GRANDMASTER_LEAGUES and MASTER_LEAGUES refer to the api resources for fetching the apex leagues of a queue
+	CHALLENGER_LEAGUES  = "league/v4/challengerleagues/by-queue/"
+	GRANDMASTER_LEAGUES = "league/v4/grandmasterleagues/by-queue/"
+	MASTER_LEAGUES      = "league/v4/masterleagues/by-queue/"
+
+	// CHAMPION_MASTERIES refers to the api resource for fetching a player's mastery of their champions
+	CHAMPION_MASTERIES = "champion-mastery/v4/champion-masteries/by-puuid/"
+
+	// MASTERY_SCORES refers to the api resource for fetching the sum of a player's champion mastery levels
+	MASTERY_SCORES = "champion-mastery/v4/scores/by-puuid/"
+
+	// ACTIVE_GAMES refers to the api resource for fetching the game a player is currently playing
+	ACTIVE_GAMES = "spectator/v5/active-games/by-summoner/"
+
+	// FEATURED_GAMES refers to the api resource for fetching the games currently featured in the client
+	FEATURED_GAMES = "spectator/v5/featured-games"
+
+	// PLATFORM_STATUS refers to the api resource for fetching maintenances and incidents of a platform
+	PLATFORM_STATUS = "status/v4/platform-data"
+
+	// CLASH_TOURNAMENTS refers to the api resource for fetching active and upcoming clash tournaments
+	CLASH_TOURNAMENTS = "clash/v1/tournaments"
+
+	// CLASH_TEAMS refers to the api resource for fetching a clash team
+	CLASH_TEAMS = "clash/v1/teams/"
+
+	// CLASH_PLAYERS_BY_SUMMONER refers to the api resource for fetching the clash registrations of a player
+	CLASH_PLAYERS_BY_SUMMONER = "clash/v1/players/by-summoner/"
+
+	// CHALLENGES_PLAYER_DATA refers to the api resource for fetching a player's challenge progress
+	CHALLENGES_PLAYER_DATA = "challenges/v1/player-data/"
+
+	// RANKED refers to the queueId referencing Summoner's Rift - Ranked Games
+	RANKED = "420"
+
+	// CLASH refers to the queueId referencing Summoner's Rift - Clash Games
+	CLASH = "700"
+)
@@ -0,0 +1,44 @@
+package riot
+
+import (
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+)
+
+// GetChampionMasteries retrieves the mastery of a player of every champion they played, ordered by champion points
+func (c *Client) GetChampionMasteries(puuid string) (*[]types.ChampionMastery, error) {
+	masteries := []types.ChampionMastery{}
+	url := c.url(c.platform, ROOT, CHAMPION_MASTERIES+puuid)
+	if err := c.get(url, &masteries); err != nil {
+		return nil, err
+	}
+	return &masteries, nil
+}
+
+// GetChampionMastery retrieves the mastery of a player of a single champion
+func (c *Client) GetChampionMastery(puuid string, championID int64) (*types.ChampionMastery, error) {
+	MasteryDTO := types.ChampionMastery{}
+	url := c.url(c.platform, ROOT, CHAMPION_MASTERIES+puuid+fmt.Sprintf("/by-champion/%d", championID))
+	if err := c.get(url, &MasteryDTO); err != nil {
+		return nil, err
+	}
+	return &MasteryDTO, nil
+}
+
+// GetTopChampionMasteries retrieves the masteries of the count champions a player has the most points with
+func (c *Client) GetTopChampionMasteries(puuid string, count int) (*[]types.ChampionMastery, error) {
+	masteries := []types.ChampionMastery{}
+	url := c.url(c.platform, ROOT, CHAMPION_MASTERIES+puuid+fmt.Sprintf("/top?count=%d", count))
+	if err := c.get(url, &masteries); err != nil {
+		return nil, err
+	}
+	return &masteries, nil
+}
+
+// GetMasteryScore retrieves the sum of the champion mastery levels of a player
+func (c *Client) GetMasteryScore(puuid string) (int, error) {
+	score := 0
+	url := c.url(c.platform, ROOT, MASTERY_SCORES+puuid)
+	err := c.get(url, &score)
+	return score, err
+}
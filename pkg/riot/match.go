@@ -0,0 +1,60 @@
+package riot
+
+import (
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+)
+
+// GetMatchList reveices the entire matchlist of the given queue of a specific player
+func (c *Client) GetMatchList(puuid string, queue string) (*[]string, error) {
+	var (
+		start     int = 0
+		count     int = 100
+		err       error
+		matches   *[]string
+		matchList []string = []string{}
+	)
+	for {
+		matches, err = c.GetPaginatedMatchList(puuid, queue, start, count)
+		if err != nil {
+			return &matchList, err
+		}
+		if len(*matches) == 0 {
+			break
+		}
+		matchList = append(matchList, *matches...)
+		start += count
+	}
+	return &matchList, nil
+}
+
+// GetPaginatedMatchList retrieves a page of the match ids of a player in the given queue
+func (c *Client) GetPaginatedMatchList(puuid string, queue string, start int, count int) (*[]string, error) {
+	matchList := []string{}
+	url := c.url(c.region, ROOT, MATCHLIST_BY_PUUID+puuid)
+	url += fmt.Sprintf("/ids?queue=%v&start=%v&count=%v", queue, start, count)
+	if err := c.get(url, &matchList); err != nil {
+		return nil, err
+	}
+	return &matchList, nil
+}
+
+// GetMatch retrieves a Match based on a gameId
+func (c *Client) GetMatch(gameID string) (*types.Match, error) {
+	MatchDTO := types.Match{}
+	url := c.url(c.region, ROOT, MATCH+gameID)
+	if err := c.getDocument(url, KIND_MATCH, gameID, &MatchDTO); err != nil {
+		return nil, err
+	}
+	return &MatchDTO, nil
+}
+
+// GetTimeline retrieves the timeline of a match, i.e. its frames and events
+func (c *Client) GetTimeline(gameID string) (*types.Timeline, error) {
+	TimelineDTO := types.Timeline{}
+	url := c.url(c.region, ROOT, MATCH+gameID+TIMELINE)
+	if err := c.get(url, &TimelineDTO); err != nil {
+		return nil, err
+	}
+	return &TimelineDTO, nil
+}
@@ -0,0 +1,70 @@
+package riot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Option func(*Client) error
+
+// WithAPIKey sets the api key sent with every request
+func WithAPIKey(key string) func(*Client) error {
+	return func(c *Client) error {
+		if key == "" {
+			return fmt.Errorf("API key must not be empty\n")
+		}
+		c.apiKey = key
+		return nil
+	}
+}
+
+// WithHTTPClient makes the client send its requests with the given http client, e.g. one with a timeout
+func WithHTTPClient(client *http.Client) func(*Client) error {
+	return func(c *Client) error {
+		if client == nil {
+			return fmt.Errorf("HTTP client must not be nil\n")
+		}
+		c.client = client
+		return nil
+	}
+}
+
+// WithBaseURL makes the client send every request to base, e.g. http://localhost:8080, instead of the host of the
+// platform or region. The path of the resources is kept, thus base may be a caching proxy or a test server
+func WithBaseURL(base string) func(*Client) error {
+	return func(c *Client) error {
+		if base == "" {
+			return fmt.Errorf("Base URL must not be empty\n")
+		}
+		c.baseURL = strings.TrimSuffix(base, "/")
+		return nil
+	}
+}
+
+// WithMaxAttempts sets the number of times a resource is requested on temporary errors before giving up on it
+func WithMaxAttempts(attempts int) func(*Client) error {
+	return func(c *Client) error {
+		if attempts < 1 {
+			return fmt.Errorf("Maximum attempts must be positive (%v)\n", attempts)
+		}
+		c.maxAttempts = attempts
+		return nil
+	}
+}
+
+// WithGate makes requests failing temporarily while the gate is paused wait for it instead of using up their attempts
+func WithGate(gate Gate) func(*Client) error {
+	return func(c *Client) error {
+		c.gate = gate
+		return nil
+	}
+}
+
+// WithResponseHook makes the client pass the exact body of every match and player response to hook
+func WithResponseHook(hook ResponseHook) func(*Client) error {
+	return func(c *Client) error {
+		c.onResponse = hook
+		return nil
+	}
+}
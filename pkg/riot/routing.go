@@ -0,0 +1,68 @@
+package riot
+
+import "fmt"
+
+// Go does not support constant maps
+var (
+	platformMap = map[string]string{
+		"BR":  "BR1",
+		"EUN": "EUN1",
+		"EUW": "EUW1",
+		"JP":  "JP1",
+		"KR":  "KR",
+		"LAN": "LA1",
+		"LAS": "LA2",
+		"ME":  "ME1",
+		"NA":  "NA1",
+		"OC":  "OC1",
+		"PH":  "PH2",
+		"RU":  "RU",
+		"SG":  "SG2",
+		"TH":  "TH2",
+		"TR":  "TR1",
+		"TW":  "TW2",
+		"VN":  "VN2",
+	}
+	// regionMap maps every platform to the regional routing value of the resources that are not served by platforms, e.g. match-v5
+	regionMap = map[string]string{
+		"BR1":  "americas",
+		"LA1":  "americas",
+		"LA2":  "americas",
+		"NA1":  "americas",
+		"JP1":  "asia",
+		"KR":   "asia",
+		"EUN1": "europe",
+		"EUW1": "europe",
+		"ME1":  "europe",
+		"RU":   "europe",
+		"TR1":  "europe",
+		"OC1":  "sea",
+		"PH2":  "sea",
+		"SG2":  "sea",
+		"TH2":  "sea",
+		"TW2":  "sea",
+		"VN2":  "sea",
+	}
+)
+
+// routing returns the platform and regional routing values of a region marker, e.g. EUW, or an error if there are none
+func routing(r string) (string, string, error) {
+	platform := platformMap[r]
+	if platform == "" {
+		return "", "", fmt.Errorf("Region %s does not exist", r)
+	}
+	region := regionMap[platform]
+	if region == "" {
+		return "", "", fmt.Errorf("Region %s does not exist", platform)
+	}
+	return platform, region, nil
+}
+
+// accountRegion returns the regional routing value of account-v1 for a region, which is not served by sea.
+// Accounts are shared among all regions, thus sea platforms are routed to the nearest one
+func accountRegion(region string) string {
+	if region == "sea" {
+		return "asia"
+	}
+	return region
+}
@@ -0,0 +1,23 @@
+package riot
+
+import types "go-league-crawler/pkg/types/lol"
+
+// GetActiveGame retrieves the game a player is currently playing. If the player is not in a game, IsNotFound reports the error
+func (c *Client) GetActiveGame(puuid string) (*types.CurrentGameInfo, error) {
+	GameDTO := types.CurrentGameInfo{}
+	url := c.url(c.platform, ROOT, ACTIVE_GAMES+puuid)
+	if err := c.get(url, &GameDTO); err != nil {
+		return nil, err
+	}
+	return &GameDTO, nil
+}
+
+// GetFeaturedGames retrieves the games currently featured in the client of the platform
+func (c *Client) GetFeaturedGames() (*types.FeaturedGames, error) {
+	FeaturedGamesDTO := types.FeaturedGames{}
+	url := c.url(c.platform, ROOT, FEATURED_GAMES)
+	if err := c.get(url, &FeaturedGamesDTO); err != nil {
+		return nil, err
+	}
+	return &FeaturedGamesDTO, nil
+}
@@ -0,0 +1,26 @@
+package riot
+
+import (
+	"encoding/json"
+	types "go-league-crawler/pkg/types/lol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GetPlatformStatus retrieves the maintenances and incidents of the platform.
+// The request is not gated, as it is the one deciding whether the platform is available
+func (c *Client) GetPlatformStatus() (*types.PlatformData, error) {
+	PlatformDataDTO := types.PlatformData{}
+	url := c.url(c.platform, ROOT, PLATFORM_STATUS)
+	log.Infof("Request URL: %v", url)
+	response, err := c.sendRequest(url, false, c.maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	err = json.NewDecoder(response.Body).Decode(&PlatformDataDTO)
+	if err != nil {
+		return nil, err
+	}
+	return &PlatformDataDTO, nil
+}
@@ -0,0 +1,44 @@
+package riot
+
+import types "go-league-crawler/pkg/types/lol"
+
+// GetPlayerByName retrieves a SummonerDTO based on a given name
+func (c *Client) GetPlayerByName(name string) (*types.Summoner, error) {
+	// Example https://euw1.api.riotgames.com/lol/summoner/v4/summoners/by-name/dwaynehart
+	SummonerDTO := types.Summoner{}
+	url := c.url(c.platform, ROOT, SUMMONERS+"by-name/"+escape(name))
+	if err := c.get(url, &SummonerDTO); err != nil {
+		return nil, err
+	}
+	return &SummonerDTO, nil
+}
+
+// GetPlayerByPUUID retrieves a SummonerDTO based on a given puuid
+func (c *Client) GetPlayerByPUUID(puuid string) (*types.Summoner, error) {
+	SummonerDTO := types.Summoner{}
+	url := c.url(c.platform, ROOT, SUMMONERS+"by-puuid/"+puuid)
+	if err := c.getDocument(url, KIND_PLAYER, puuid, &SummonerDTO); err != nil {
+		return nil, err
+	}
+	return &SummonerDTO, nil
+}
+
+// GetPlayerBySummonerID retrieves a SummonerDTO based on a given summonerId
+func (c *Client) GetPlayerBySummonerID(summonerID string) (*types.Summoner, error) {
+	SummonerDTO := types.Summoner{}
+	url := c.url(c.platform, ROOT, SUMMONERS+summonerID)
+	if err := c.get(url, &SummonerDTO); err != nil {
+		return nil, err
+	}
+	return &SummonerDTO, nil
+}
+
+// GetPlayerByAccountID retrieves a SummonerDTO based on a given accountId
+func (c *Client) GetPlayerByAccountID(accountID string) (*types.Summoner, error) {
+	SummonerDTO := types.Summoner{}
+	url := c.url(c.platform, ROOT, SUMMONERS+"by-account/"+accountID)
+	if err := c.get(url, &SummonerDTO); err != nil {
+		return nil, err
+	}
+	return &SummonerDTO, nil
+}
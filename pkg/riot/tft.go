@@ -0,0 +1,106 @@
+package riot
+
+import (
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	tft "go-league-crawler/pkg/types/tft"
+)
+
+const (
+	// TFT_ROOT refers to the root of the tft api resources
+	TFT_ROOT = ".api.riotgames.com/tft/"
+
+	// TFT_SUMMONERS refers to the api resource for fetching information about tft players
+	TFT_SUMMONERS = "summoner/v1/summoners/"
+
+	// TFT_MATCHLIST_BY_PUUID refers to the api resource for fetching a player's tft match history
+	TFT_MATCHLIST_BY_PUUID = "match/v1/matches/by-puuid/"
+
+	// TFT_MATCH refers to the api resource for fetching a tft match dto
+	TFT_MATCH = "match/v1/matches/"
+
+	// TFT_CHALLENGER refers to the api resource for fetching the challenger league of ranked tft
+	TFT_CHALLENGER = "league/v1/challenger"
+)
+
+// GetTFTPlayerByName retrieves a SummonerDTO from tft-summoner-v1 based on a given name
+func (c *Client) GetTFTPlayerByName(name string) (*types.Summoner, error) {
+	SummonerDTO := types.Summoner{}
+	url := c.url(c.platform, TFT_ROOT, TFT_SUMMONERS+"by-name/"+escape(name))
+	if err := c.getPlayerDocument(url, KIND_TFT_PLAYER, &SummonerDTO); err != nil {
+		return nil, err
+	}
+	return &SummonerDTO, nil
+}
+
+// GetTFTPlayerByPUUID retrieves a SummonerDTO from tft-summoner-v1 based on a given puuid
+func (c *Client) GetTFTPlayerByPUUID(puuid string) (*types.Summoner, error) {
+	SummonerDTO := types.Summoner{}
+	url := c.url(c.platform, TFT_ROOT, TFT_SUMMONERS+"by-puuid/"+puuid)
+	if err := c.getDocument(url, KIND_TFT_PLAYER, puuid, &SummonerDTO); err != nil {
+		return nil, err
+	}
+	return &SummonerDTO, nil
+}
+
+// GetTFTPlayerBySummonerID retrieves a SummonerDTO from tft-summoner-v1 based on a given summonerId, e.g. of a league entry
+func (c *Client) GetTFTPlayerBySummonerID(summonerID string) (*types.Summoner, error) {
+	SummonerDTO := types.Summoner{}
+	url := c.url(c.platform, TFT_ROOT, TFT_SUMMONERS+escape(summonerID))
+	if err := c.getPlayerDocument(url, KIND_TFT_PLAYER, &SummonerDTO); err != nil {
+		return nil, err
+	}
+	return &SummonerDTO, nil
+}
+
+// GetTFTMatchList receives the entire tft matchlist of a player
+func (c *Client) GetTFTMatchList(puuid string) (*[]string, error) {
+	var (
+		start     int = 0
+		count     int = 100
+		matchList []string
+	)
+	for {
+		matches, err := c.GetPaginatedTFTMatchList(puuid, start, count)
+		if err != nil {
+			return &matchList, err
+		}
+		if len(*matches) == 0 {
+			break
+		}
+		matchList = append(matchList, *matches...)
+		start += count
+	}
+	return &matchList, nil
+}
+
+// GetPaginatedTFTMatchList retrieves a paginated list of tft match ids of a player
+func (c *Client) GetPaginatedTFTMatchList(puuid string, start int, count int) (*[]string, error) {
+	matchList := []string{}
+	url := c.url(c.region, TFT_ROOT, TFT_MATCHLIST_BY_PUUID+puuid)
+	url += fmt.Sprintf("/ids?start=%v&count=%v", start, count)
+	if err := c.get(url, &matchList); err != nil {
+		return nil, err
+	}
+	return &matchList, nil
+}
+
+// GetTFTMatch retrieves a tft Match based on its id
+func (c *Client) GetTFTMatch(matchID string) (*tft.Match, error) {
+	MatchDTO := tft.Match{}
+	url := c.url(c.region, TFT_ROOT, TFT_MATCH+matchID)
+	if err := c.getDocument(url, KIND_TFT_MATCH, matchID, &MatchDTO); err != nil {
+		return nil, err
+	}
+	return &MatchDTO, nil
+}
+
+// GetTFTChallengerLeague retrieves the challenger league of ranked tft
+func (c *Client) GetTFTChallengerLeague() (*tft.League, error) {
+	LeagueDTO := tft.League{}
+	url := c.url(c.platform, TFT_ROOT, TFT_CHALLENGER)
+	if err := c.get(url, &LeagueDTO); err != nil {
+		return nil, err
+	}
+	return &LeagueDTO, nil
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	tft "go-league-crawler/pkg/types/tft"
+	"io/ioutil"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Kinds of raw documents
+const (
+	KIND_MATCH      = "match"
+	KIND_PLAYER     = "player"
+	KIND_TFT_MATCH  = "tft-match"
+	KIND_TFT_PLAYER = "tft-player"
+)
+
+// Compressions of raw documents
+const (
+	GZIP = "gzip"
+	ZSTD = "zstd"
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// RawDocument keeps the exact bytes of an api response in compressed form, so that it can be decoded again
+// once the types have changed. FetchedAt is given in unix milliseconds
+type RawDocument struct {
+	Kind        string `bson:"kind" json:"kind"`
+	ID          string `bson:"id" json:"id"`
+	Compression string `bson:"compression" json:"compression"`
+	Data        []byte `bson:"data" json:"data"`
+	FetchedAt   int64  `bson:"fetchedAt" json:"fetchedAt"`
+}
+
+// Archive is implemented by DBManagers that are able to keep raw api responses
+type Archive interface {
+	InsertRaw(doc RawDocument) error
+	// IterateRaw calls fn for every raw document of the given kind until fn returns an error
+	IterateRaw(kind string, fn func(RawDocument) error) error
+}
+
+// Reprocessor is implemented by Archives that are able to replace the typed documents decoded from their raw documents
+type Reprocessor interface {
+	Archive
+	ReplaceMatch(match types.Match) error
+	ReplacePlayer(player types.Summoner) error
+	ReplaceTFTMatch(match tft.Match) error
+	ReplaceTFTPlayer(player types.Summoner) error
+}
+
+// NewRawDocument compresses an api response
+func NewRawDocument(kind string, id string, compression string, data []byte) (RawDocument, error) {
+	doc := RawDocument{
+		Kind:        kind,
+		ID:          id,
+		Compression: compression,
+		FetchedAt:   time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	switch compression {
+	case GZIP:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return doc, err
+		}
+		if err := w.Close(); err != nil {
+			return doc, err
+		}
+		doc.Data = buf.Bytes()
+	case ZSTD:
+		doc.Data = zstdEncoder.EncodeAll(data, nil)
+	default:
+		return doc, fmt.Errorf("Unknown compression %v", compression)
+	}
+	return doc, nil
+}
+
+// Bytes returns the decompressed api response
+func (d RawDocument) Bytes() ([]byte, error) {
+	switch d.Compression {
+	case GZIP:
+		r, err := gzip.NewReader(bytes.NewReader(d.Data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	case ZSTD:
+		return zstdDecoder.DecodeAll(d.Data, nil)
+	default:
+		return nil, fmt.Errorf("Unknown compression %v", d.Compression)
+	}
+}
+
+// Reprocess decodes every raw document of the given kind into the current types and replaces
+// the typed documents with them. It returns the number of documents that have been reprocessed
+func Reprocess(rp Reprocessor, kind string) (int, error) {
+	n := 0
+	err := rp.IterateRaw(kind, func(doc RawDocument) error {
+		data, err := doc.Bytes()
+		if err != nil {
+			return fmt.Errorf("%v %v: %v", kind, doc.ID, err)
+		}
+		switch kind {
+		case KIND_MATCH:
+			match := types.Match{}
+			if err = json.Unmarshal(data, &match); err == nil {
+				err = rp.ReplaceMatch(match)
+			}
+		case KIND_PLAYER:
+			player := types.Summoner{}
+			if err = json.Unmarshal(data, &player); err == nil {
+				err = rp.ReplacePlayer(player)
+			}
+		case KIND_TFT_MATCH:
+			match := tft.Match{}
+			if err = json.Unmarshal(data, &match); err == nil {
+				err = rp.ReplaceTFTMatch(match)
+			}
+		case KIND_TFT_PLAYER:
+			player := types.Summoner{}
+			if err = json.Unmarshal(data, &player); err == nil {
+				err = rp.ReplaceTFTPlayer(player)
+			}
+		default:
+			err = fmt.Errorf("Unknown kind")
+		}
+		if err != nil {
+			return fmt.Errorf("%v %v: %v", kind, doc.ID, err)
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
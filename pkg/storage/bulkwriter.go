@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DUPLICATE_KEY is the error code of a write that violated a unique index,
+	// e.g. an upsert that lost against a concurrent upsert of the same document
+	DUPLICATE_KEY = 11000
+
+	DefaultBulkMaxAttempts = 5
+)
+
+// BulkWriter writes the models of a collection asynchronously in bulk, as soon as BatchSize models
+// have been collected or FlushInterval has passed since the last write, whichever comes first.
+// Writes block once the writer has fallen behind by another batch, which slows down its producers
+type BulkWriter struct {
+	coll          *mongo.Collection
+	batchSize     int
+	flushInterval time.Duration
+	maxAttempts   int
+	queue         chan mongo.WriteModel
+	done          chan struct{}
+	// OnError is called with the models that could not be written, after transient errors have been retried
+	OnError func(coll string, models []mongo.WriteModel, err error)
+	// OnWrite is called with the models that have been written, if it is not nil
+	OnWrite func(coll string, models []mongo.WriteModel)
+}
+
+// NewBulkWriter returns a running BulkWriter for the given collection
+func NewBulkWriter(coll *mongo.Collection, batchSize int, flushInterval time.Duration) *BulkWriter {
+	w := &BulkWriter{
+		coll:          coll,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxAttempts:   DefaultBulkMaxAttempts,
+		queue:         make(chan mongo.WriteModel, batchSize),
+		done:          make(chan struct{}),
+		OnError: func(coll string, models []mongo.WriteModel, err error) {
+			log.Errorf("Could not write %d documents to %v: %v", len(models), coll, err)
+		},
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a model to be written with the next batch
+func (w *BulkWriter) Write(model mongo.WriteModel) {
+	select {
+	case w.queue <- model:
+	default:
+		log.Warnf("Bulk writer of %v has fallen behind, waiting for it to catch up", w.coll.Name())
+		w.queue <- model
+	}
+}
+
+// Close writes the remaining models and stops the writer. Write must not be called afterwards
+func (w *BulkWriter) Close() error {
+	close(w.queue)
+	<-w.done
+	return nil
+}
+
+func (w *BulkWriter) run() {
+	defer close(w.done)
+	batch := make([]mongo.WriteModel, 0, w.batchSize)
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case model, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, model)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = make([]mongo.WriteModel, 0, w.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = make([]mongo.WriteModel, 0, w.batchSize)
+			}
+		}
+	}
+}
+
+// flush writes a batch, retrying the models that failed due to transient errors
+func (w *BulkWriter) flush(batch []mongo.WriteModel) {
+	for attempt := 1; len(batch) > 0; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+		res, err := w.coll.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+		cancel()
+		if err == nil {
+			log.Debugf("Bulk wrote %d documents to %v (%d new)", len(batch), w.coll.Name(), res.UpsertedCount+res.InsertedCount)
+			w.written(batch)
+			return
+		}
+		retry, failed, written := classify(batch, err)
+		w.written(written)
+		if len(failed) > 0 {
+			w.OnError(w.coll.Name(), failed, err)
+		}
+		if len(retry) > 0 && attempt >= w.maxAttempts {
+			w.OnError(w.coll.Name(), retry, fmt.Errorf("Maximum Attempts (%d) reached: %v", w.maxAttempts, err))
+			return
+		}
+		if len(retry) > 0 {
+			log.Warnf("Temporary Error writing %d documents to %v at attempt No. %d: %v", len(retry), w.coll.Name(), attempt, err)
+			time.Sleep(time.Second * time.Duration(attempt))
+		}
+		batch = retry
+	}
+}
+
+// written passes the models that have been written to OnWrite
+func (w *BulkWriter) written(models []mongo.WriteModel) {
+	if w.OnWrite != nil && len(models) > 0 {
+		w.OnWrite(w.coll.Name(), models)
+	}
+}
+
+// classify splits a batch that could not be written into the models worth retrying, the ones that failed for good
+// and the ones that have been written nevertheless
+func classify(batch []mongo.WriteModel, err error) ([]mongo.WriteModel, []mongo.WriteModel, []mongo.WriteModel) {
+	retry, failed, written := []mongo.WriteModel{}, []mongo.WriteModel{}, []mongo.WriteModel{}
+	var bwe mongo.BulkWriteException
+	if errors.As(err, &bwe) && bwe.WriteConcernError == nil && len(bwe.WriteErrors) > 0 {
+		// Only the models with write errors have not been written
+		erroneous := map[int]bool{}
+		for _, we := range bwe.WriteErrors {
+			erroneous[we.Index] = true
+			if we.Code == DUPLICATE_KEY {
+				retry = append(retry, batch[we.Index])
+			} else {
+				failed = append(failed, batch[we.Index])
+			}
+		}
+		for i, model := range batch {
+			if !erroneous[i] {
+				written = append(written, model)
+			}
+		}
+		return retry, failed, written
+	}
+	if isTransient(err) {
+		return batch, failed, written
+	}
+	return retry, batch, written
+}
+
+// ModelIDs returns the ids of the documents written by models, i.e. the values the filters of the models select them by
+func ModelIDs(models []mongo.WriteModel) []string {
+	ids := []string{}
+	for _, model := range models {
+		var filter interface{}
+		switch m := model.(type) {
+		case *mongo.UpdateOneModel:
+			filter = m.Filter
+		case *mongo.ReplaceOneModel:
+			filter = m.Filter
+		}
+		if f, ok := filter.(bson.M); ok {
+			for _, v := range f {
+				if id, ok := v.(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func isTransient(err error) bool {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+	var se mongo.ServerError
+	return errors.As(err, &se) && (se.HasErrorLabel("RetryableWriteError") || se.HasErrorLabel("TransientTransactionError"))
+}
@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	tft "go-league-crawler/pkg/types/tft"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Failure policies of the sinks of a CompositeManager
+const (
+	// REQUIRED sinks fail a write when they fail
+	REQUIRED = "required"
+	// BEST_EFFORT sinks only count and log their failures
+	BEST_EFFORT = "best-effort"
+)
+
+// Sink is a DBManager a CompositeManager writes to
+type Sink struct {
+	Name   string
+	Policy string
+	DBManager
+}
+
+// SinkStats are the metrics of a sink of a CompositeManager
+type SinkStats struct {
+	Name      string
+	Policy    string
+	Writes    uint64
+	Errors    uint64
+	LastError string
+}
+
+type sink struct {
+	Sink
+	writes    uint64
+	errors    uint64
+	mu        sync.Mutex
+	lastError error
+}
+
+// CompositeManager writes every match and player to several sinks, e.g. MongoDB and a file archive.
+// A write fails if one of the REQUIRED sinks fails, the failures of BEST_EFFORT sinks are only counted.
+// Optional capabilities like ClashManager are forwarded to every sink that implements them, reads to the first one.
+// It implements the interfaces of every capability, Supports tells which ones its sinks are able to use
+type CompositeManager struct {
+	sinks []*sink
+}
+
+// NewCompositeManager returns a CompositeManager writing to the given sinks in their order
+func NewCompositeManager(sinks ...Sink) (*CompositeManager, error) {
+	cm := &CompositeManager{}
+	if len(sinks) == 0 {
+		return cm, fmt.Errorf("A composite manager needs at least one sink")
+	}
+	for _, s := range sinks {
+		if s.Policy != REQUIRED && s.Policy != BEST_EFFORT {
+			return cm, fmt.Errorf("Unknown policy %v of sink %v (%v or %v)", s.Policy, s.Name, REQUIRED, BEST_EFFORT)
+		}
+		cm.sinks = append(cm.sinks, &sink{Sink: s})
+	}
+	return cm, nil
+}
+
+// Supports reports whether a sink supports the capability
+func (cm *CompositeManager) Supports(capability string) bool {
+	for _, s := range cm.sinks {
+		if Supports(s.DBManager, capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the number of writes and errors of every sink
+func (cm *CompositeManager) Stats() []SinkStats {
+	stats := make([]SinkStats, len(cm.sinks))
+	for i, s := range cm.sinks {
+		stats[i] = SinkStats{
+			Name:   s.Name,
+			Policy: s.Policy,
+			Writes: atomic.LoadUint64(&s.writes),
+			Errors: atomic.LoadUint64(&s.errors),
+		}
+		s.mu.Lock()
+		if s.lastError != nil {
+			stats[i].LastError = s.lastError.Error()
+		}
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+// Close closes every sink and logs their stats
+func (cm *CompositeManager) Close() error {
+	var errs []string
+	for _, s := range cm.sinks {
+		if closer, ok := s.DBManager.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Sprintf("%v: %v", s.Name, err))
+			}
+		}
+	}
+	for _, st := range cm.Stats() {
+		log.WithFields(log.Fields{
+			"Policy":     st.Policy,
+			"Writes":     st.Writes,
+			"Errors":     st.Errors,
+			"Last Error": st.LastError,
+		}).Infof("Sink %v", st.Name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Could not close sinks: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// fanOut calls write for every sink, write returning false if the sink does not support the operation.
+// It fails if a REQUIRED sink failed or no sink supports the operation
+func (cm *CompositeManager) fanOut(op string, write func(s *sink) (bool, error)) error {
+	var errs []string
+	supported := false
+	for _, s := range cm.sinks {
+		ok, err := write(s)
+		if !ok {
+			continue
+		}
+		supported = true
+		atomic.AddUint64(&s.writes, 1)
+		if err == nil {
+			continue
+		}
+		atomic.AddUint64(&s.errors, 1)
+		s.mu.Lock()
+		s.lastError = err
+		s.mu.Unlock()
+		if s.Policy == REQUIRED {
+			errs = append(errs, fmt.Sprintf("%v: %v", s.Name, err))
+		} else {
+			log.Warnf("Best-effort sink %v failed to %v: %v", s.Name, op, err)
+		}
+	}
+	if !supported {
+		return fmt.Errorf("No sink is able to %v (%w)", op, ErrUnsupported)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Could not %v: %v", op, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (cm *CompositeManager) InsertMatch(match types.Match) error {
+	return cm.fanOut("insert match "+match.MetaData.MatchID, func(s *sink) (bool, error) {
+		return true, s.InsertMatch(match)
+	})
+}
+
+func (cm *CompositeManager) InsertPlayer(player types.Summoner) error {
+	return cm.fanOut("insert player "+player.Puuid, func(s *sink) (bool, error) {
+		return true, s.InsertPlayer(player)
+	})
+}
+
+func (cm *CompositeManager) InsertClashTeam(team types.ClashTeam) error {
+	return cm.fanOut("insert clash team "+team.ID, func(s *sink) (bool, error) {
+		if m, ok := s.DBManager.(ClashManager); ok {
+			return true, m.InsertClashTeam(team)
+		}
+		return false, nil
+	})
+}
+
+func (cm *CompositeManager) LinkClashMatch(teamID string, matchID string) error {
+	return cm.fanOut("link clash match "+matchID, func(s *sink) (bool, error) {
+		if m, ok := s.DBManager.(ClashManager); ok {
+			return true, m.LinkClashMatch(teamID, matchID)
+		}
+		return false, nil
+	})
+}
+
+func (cm *CompositeManager) InsertTFTMatch(match tft.Match) error {
+	return cm.fanOut("insert tft match "+match.MetaData.MatchID, func(s *sink) (bool, error) {
+		if m, ok := s.DBManager.(TFTManager); ok {
+			return true, m.InsertTFTMatch(match)
+		}
+		return false, nil
+	})
+}
+
+func (cm *CompositeManager) InsertTFTPlayer(player types.Summoner) error {
+	return cm.fanOut("insert tft player "+player.Puuid, func(s *sink) (bool, error) {
+		if m, ok := s.DBManager.(TFTManager); ok {
+			return true, m.InsertTFTPlayer(player)
+		}
+		return false, nil
+	})
+}
+
+func (cm *CompositeManager) InsertPlayerChallenges(challenges types.PlayerChallenges) error {
+	return cm.fanOut("insert challenges of "+challenges.Puuid, func(s *sink) (bool, error) {
+		if m, ok := s.DBManager.(ChallengeManager); ok {
+			return true, m.InsertPlayerChallenges(challenges)
+		}
+		return false, nil
+	})
+}
+
+func (cm *CompositeManager) InsertRaw(doc RawDocument) error {
+	return cm.fanOut("insert raw "+doc.Kind+" "+doc.ID, func(s *sink) (bool, error) {
+		if m, ok := s.DBManager.(Archive); ok {
+			return true, m.InsertRaw(doc)
+		}
+		return false, nil
+	})
+}
+
+// IterateRaw iterates the raw documents of the first sink that keeps them
+func (cm *CompositeManager) IterateRaw(kind string, fn func(RawDocument) error) error {
+	for _, s := range cm.sinks {
+		if m, ok := s.DBManager.(Archive); ok {
+			return m.IterateRaw(kind, fn)
+		}
+	}
+	return fmt.Errorf("No sink keeps raw documents (%w)", ErrUnsupported)
+}
+
+// IterateMatches iterates the matches of the first sink that is able to read them back
+func (cm *CompositeManager) IterateMatches(fn func(types.Match) error) error {
+	for _, s := range cm.sinks {
+		if m, ok := s.DBManager.(MatchIterator); ok {
+			return m.IterateMatches(fn)
+		}
+	}
+	return fmt.Errorf("No sink is able to read matches back (%w)", ErrUnsupported)
+}
+
+// reader returns the first sink that is able to query matches
+func (cm *CompositeManager) reader() (MatchReader, error) {
+	for _, s := range cm.sinks {
+		if m, ok := s.DBManager.(MatchReader); ok {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("No sink is able to query matches (%w)", ErrUnsupported)
+}
+
+func (cm *CompositeManager) GetMatch(matchID string) (types.Match, error) {
+	r, err := cm.reader()
+	if err != nil {
+		return types.Match{}, err
+	}
+	return r.GetMatch(matchID)
+}
+
+func (cm *CompositeManager) MatchExists(matchID string) (bool, error) {
+	r, err := cm.reader()
+	if err != nil {
+		return false, err
+	}
+	return r.MatchExists(matchID)
+}
+
+// FindMatches queries the first sink that is able to, falling back to scanning the first one able to read matches back
+func (cm *CompositeManager) FindMatches(query MatchQuery, fn func(types.Match) error) error {
+	r, err := cm.reader()
+	if err != nil {
+		for _, s := range cm.sinks {
+			if _, ok := s.DBManager.(MatchIterator); ok {
+				return FindMatches(s.DBManager, query, fn)
+			}
+		}
+		return err
+	}
+	return r.FindMatches(query, fn)
+}
+
+func (cm *CompositeManager) CountMatches(query MatchQuery) (int64, error) {
+	r, err := cm.reader()
+	if err != nil {
+		return 0, err
+	}
+	return r.CountMatches(query)
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Levels of the rows of a CSVWriter
+const (
+	LEVEL_PARTICIPANT = "participant"
+	LEVEL_TEAM        = "team"
+)
+
+// FieldPath is a column of a csv export, given as the path of json fields within a match. Paths starting with participants or teams
+// refer to the participant or team of the row, paths starting with info or metadata to the match
+type FieldPath struct {
+	Name  string
+	Scope string
+	Keys  []string
+}
+
+// ParseFieldPaths parses a comma separated list of field paths, e.g. info.gameVersion,participants.championName,participants.win,
+// for the rows of a level
+func ParseFieldPaths(list string, level string) ([]FieldPath, error) {
+	if level != LEVEL_PARTICIPANT && level != LEVEL_TEAM {
+		return nil, fmt.Errorf("Unknown level %v (%v or %v)", level, LEVEL_PARTICIPANT, LEVEL_TEAM)
+	}
+	paths := []FieldPath{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		keys := strings.Split(strings.TrimPrefix(name, "info."), ".")
+		if strings.HasPrefix(name, "info.") && keys[0] != "participants" && keys[0] != "teams" {
+			keys = append([]string{"info"}, keys...)
+		}
+		if len(keys) < 2 {
+			return nil, fmt.Errorf("Invalid field path %q", name)
+		}
+		switch keys[0] {
+		case "participants":
+			if level == LEVEL_TEAM {
+				return nil, fmt.Errorf("Field path %q refers to participants, which are not part of team-level rows", name)
+			}
+		case "teams", "info", "metadata":
+		default:
+			return nil, fmt.Errorf("Field path %q needs to start with info, metadata, participants or teams", name)
+		}
+		paths = append(paths, FieldPath{Name: name, Scope: keys[0], Keys: keys[1:]})
+	}
+	return paths, nil
+}
+
+// CSVWriter is a DBManager writing the selected columns of every participant or team of a match as a csv row
+type CSVWriter struct {
+	w     *csv.Writer
+	paths []FieldPath
+	level string
+	rows  int
+}
+
+// NewCSVWriter returns a CSVWriter writing a row per participant or team (level) to w
+func NewCSVWriter(w io.Writer, paths []FieldPath, level string) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), paths: paths, level: level}
+}
+
+// WriteHeader writes the names of the field paths as the header row
+func (cw *CSVWriter) WriteHeader() error {
+	header := make([]string, len(cw.paths))
+	for i, p := range cw.paths {
+		header[i] = p.Name
+	}
+	return cw.w.Write(header)
+}
+
+// Rows returns the number of rows written so far, not counting the header
+func (cw *CSVWriter) Rows() int {
+	return cw.rows
+}
+
+// Flush writes any buffered rows
+func (cw *CSVWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *CSVWriter) InsertMatch(match types.Match) error {
+	data, err := json.Marshal(match)
+	if err != nil {
+		return err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	info, _ := doc["info"].(map[string]interface{})
+	teams := map[string]interface{}{}
+	for _, t := range asSlice(info["teams"]) {
+		if team, ok := t.(map[string]interface{}); ok {
+			teams[fmt.Sprint(team["teamId"])] = team
+		}
+	}
+	rows := asSlice(info["participants"])
+	if cw.level == LEVEL_TEAM {
+		rows = asSlice(info["teams"])
+	}
+	for _, r := range rows {
+		row, _ := r.(map[string]interface{})
+		scopes := map[string]interface{}{"info": info, "metadata": doc["metadata"], "participants": row, "teams": row}
+		if cw.level == LEVEL_PARTICIPANT {
+			scopes["teams"] = teams[fmt.Sprint(row["teamId"])]
+		}
+		record := make([]string, len(cw.paths))
+		for i, p := range cw.paths {
+			record[i] = formatValue(lookup(scopes[p.Scope], p.Keys))
+		}
+		if err := cw.w.Write(record); err != nil {
+			return err
+		}
+		cw.rows++
+	}
+	return cw.w.Error()
+}
+
+func (cw *CSVWriter) InsertPlayer(player types.Summoner) error {
+	return nil
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+// lookup follows keys through nested json objects, returning nil if a key does not exist
+func lookup(v interface{}, keys []string) interface{} {
+	for _, k := range keys {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[k]
+	}
+	return v
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
@@ -1,15 +1,85 @@
 package storage
 
-import types "go-league-crawler/pkg/types/lol"
+import (
+	types "go-league-crawler/pkg/types/lol"
+	tft "go-league-crawler/pkg/types/tft"
+)
 
 type DBManager interface {
 	InsertMatch(match types.Match) error
 	InsertPlayer(player types.Summoner) error
 }
 
+// ClashManager is implemented by DBManagers that are able to store clash teams as their own entity
+type ClashManager interface {
+	InsertClashTeam(team types.ClashTeam) error
+	LinkClashMatch(teamID string, matchID string) error
+}
+
+// TFTManager is implemented by DBManagers that are able to store tft matches and players apart from lol ones
+type TFTManager interface {
+	InsertTFTMatch(match tft.Match) error
+	InsertTFTPlayer(player types.Summoner) error
+}
+
+// ChallengeManager is implemented by DBManagers that are able to store snapshots of the challenge progress of players
+type ChallengeManager interface {
+	InsertPlayerChallenges(challenges types.PlayerChallenges) error
+}
+
+// MatchIterator is implemented by DBManagers that are able to read their matches back
+type MatchIterator interface {
+	// IterateMatches calls fn for every stored match until fn returns an error
+	IterateMatches(fn func(types.Match) error) error
+}
+
+// Capabilities of DBManagers beyond storing lol matches and players, each referring to the interface it requires
+const (
+	CAPABILITY_CLASH      = "clash"      // ClashManager
+	CAPABILITY_TFT        = "tft"        // TFTManager
+	CAPABILITY_CHALLENGES = "challenges" // ChallengeManager
+	CAPABILITY_ARCHIVE    = "archive"    // Archive
+	CAPABILITY_READ       = "read"       // MatchReader
+)
+
+// CapabilityReporter is implemented by DBManagers that implement the interfaces of capabilities they are not always able to use,
+// e.g. the CompositeManager, which depends on its sinks
+type CapabilityReporter interface {
+	Supports(capability string) bool
+}
+
+// Supports reports whether a DBManager is able to make use of a capability. The interface of the capability is required
+// in any case, a CapabilityReporter is asked in addition
+func Supports(dbm DBManager, capability string) bool {
+	var ok bool
+	switch capability {
+	case CAPABILITY_CLASH:
+		_, ok = dbm.(ClashManager)
+	case CAPABILITY_TFT:
+		_, ok = dbm.(TFTManager)
+	case CAPABILITY_CHALLENGES:
+		_, ok = dbm.(ChallengeManager)
+	case CAPABILITY_ARCHIVE:
+		_, ok = dbm.(Archive)
+	case CAPABILITY_READ:
+		_, ok = dbm.(MatchReader)
+	}
+	if r, isReporter := dbm.(CapabilityReporter); ok && isReporter {
+		return r.Supports(capability)
+	}
+	return ok
+}
+
 type DB struct {
-	Host          string
-	Database      string
-	MatchStorage  string
-	PlayerStorage string
+	Host             string
+	Database         string
+	MatchStorage     string
+	PlayerStorage    string
+	ClashTeamStorage string
+	TFTMatchStorage  string
+	TFTPlayerStorage string
+	ChallengeStorage string
+	RawStorage       string
+	// CrawlerVersion is stamped onto every stored document
+	CrawlerVersion string
 }
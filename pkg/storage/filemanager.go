@@ -0,0 +1,367 @@
+package storage
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// Partition keys of a FileManager
+const (
+	PARTITION_DATE     = "date"
+	PARTITION_PLATFORM = "platform"
+	PARTITION_PATCH    = "patch"
+)
+
+const (
+	DefaultFileMaxSize = 128 << 20
+	DefaultFileMaxAge  = time.Hour
+)
+
+// FileManager writes matches and players as newline-delimited JSON into compressed files below a directory, e.g.
+// <dir>/matches/date=2024-01-31/platform=EUW1/patch=14.2/matches-20240131T120000-0001.jsonl.gz.
+// Matches are partitioned by the date (UTC) of their creation, their platform and their patch, players by the date they have been crawled at.
+// A file is rotated once it exceeds a size or an age. Until then it is hidden (.<name>.tmp) and only renamed when it is complete,
+// so that readers never see a partially written file. Documents are not deduplicated across runs
+type FileManager struct {
+	dir         string
+	compression string
+	partitions  []string
+	maxSize     int64
+	maxAge      time.Duration
+	// CrawlerVersion is stamped onto every written match and player
+	CrawlerVersion string
+	mu             sync.Mutex
+	files          map[string]*jsonlFile
+	seq            int
+	quit           chan struct{}
+	done           chan struct{}
+}
+
+type FileOption func(*FileManager) error
+
+// WithFileCompression compresses the files with GZIP (default) or ZSTD
+func WithFileCompression(compression string) func(*FileManager) error {
+	return func(fm *FileManager) error {
+		if compression != GZIP && compression != ZSTD {
+			return fmt.Errorf("Unknown compression %v", compression)
+		}
+		fm.compression = compression
+		return nil
+	}
+}
+
+// WithPartitions sets the keys matches are partitioned by, in the order of the directories. Players are always partitioned by date
+func WithPartitions(keys ...string) func(*FileManager) error {
+	return func(fm *FileManager) error {
+		for _, k := range keys {
+			if k != PARTITION_DATE && k != PARTITION_PLATFORM && k != PARTITION_PATCH {
+				return fmt.Errorf("Unknown partition key %v (date, platform or patch)", k)
+			}
+		}
+		fm.partitions = keys
+		return nil
+	}
+}
+
+// WithRotation finalizes a file once maxSize (compressed bytes) has been written to it or maxAge has passed since it has been created
+func WithRotation(maxSize int64, maxAge time.Duration) func(*FileManager) error {
+	return func(fm *FileManager) error {
+		if maxSize < 1 || maxAge <= 0 {
+			return fmt.Errorf("Maximum Size and Age of files need to be positive (%v, %v)", maxSize, maxAge)
+		}
+		fm.maxSize = maxSize
+		fm.maxAge = maxAge
+		return nil
+	}
+}
+
+// NewFileManager returns a FileManager writing below dir
+func NewFileManager(dir string, opts ...FileOption) (*FileManager, error) {
+	fm := &FileManager{
+		dir:         dir,
+		compression: GZIP,
+		partitions:  []string{PARTITION_DATE, PARTITION_PLATFORM, PARTITION_PATCH},
+		maxSize:     DefaultFileMaxSize,
+		maxAge:      DefaultFileMaxAge,
+		files:       make(map[string]*jsonlFile),
+		quit:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if err := opt(fm); err != nil {
+			return fm, err
+		}
+	}
+	return fm, nil
+}
+
+// Init creates the directory and starts rotating files by age
+func (fm *FileManager) Init() error {
+	if err := os.MkdirAll(fm.dir, 0755); err != nil {
+		return err
+	}
+	leftovers := 0
+	filepath.Walk(fm.dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasPrefix(info.Name(), ".") && strings.HasSuffix(info.Name(), ".tmp") {
+			leftovers++
+		}
+		return nil
+	})
+	if leftovers > 0 {
+		log.Warnf("%d incomplete files of a previous run are left in %v", leftovers, fm.dir)
+	}
+	fm.done = make(chan struct{})
+	go fm.run()
+	return nil
+}
+
+// Close finalizes all open files
+func (fm *FileManager) Close() error {
+	if fm.done != nil {
+		close(fm.quit)
+		<-fm.done
+		fm.done = nil
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	var err error
+	for key, f := range fm.files {
+		if ferr := f.finalize(); ferr != nil && err == nil {
+			err = ferr
+		}
+		delete(fm.files, key)
+	}
+	return err
+}
+
+// InsertMatch appends a match to the file of its partition
+func (fm *FileManager) InsertMatch(match types.Match) error {
+	match.SchemaVersion, match.CrawlerVersion = types.SchemaVersion, fm.CrawlerVersion
+	values := map[string]string{
+		PARTITION_DATE:     time.Unix(0, match.Info.GameCreation*int64(time.Millisecond)).UTC().Format("2006-01-02"),
+		PARTITION_PLATFORM: match.Info.PlatformID,
+		PARTITION_PATCH:    match.Patch(),
+	}
+	partition := []string{MATCH_COLLECTION}
+	for _, k := range fm.partitions {
+		partition = append(partition, k+"="+values[k])
+	}
+	return fm.write(partition, match)
+}
+
+// InsertPlayer appends a player to the file of the current date
+func (fm *FileManager) InsertPlayer(player types.Summoner) error {
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, fm.CrawlerVersion
+	partition := []string{PLAYER_COLLECTION, PARTITION_DATE + "=" + time.Now().UTC().Format("2006-01-02")}
+	return fm.write(partition, player)
+}
+
+func (fm *FileManager) write(partition []string, doc interface{}) error {
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	key := filepath.Join(partition...)
+	f, ok := fm.files[key]
+	if !ok {
+		if f, err = fm.create(partition); err != nil {
+			return err
+		}
+		fm.files[key] = f
+	}
+	if _, err := f.Write(line); err != nil {
+		return err
+	}
+	if f.size() >= fm.maxSize {
+		delete(fm.files, key)
+		return f.finalize()
+	}
+	return nil
+}
+
+// IterateMatches calls fn for every match of the finalized files until fn returns an error
+func (fm *FileManager) IterateMatches(fn func(types.Match) error) error {
+	return filepath.Walk(filepath.Join(fm.dir, MATCH_COLLECTION), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		return readJSONL(path, func(line []byte) error {
+			match := types.Match{}
+			if err := json.Unmarshal(line, &match); err != nil {
+				return fmt.Errorf("%v: %v", path, err)
+			}
+			return fn(match)
+		})
+	})
+}
+
+// readJSONL calls fn for every line of a compressed file of newline-delimited JSON
+func readJSONL(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var r io.Reader
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(path, ".zst"):
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// create opens a new hidden file in the directory of the partition
+func (fm *FileManager) create(partition []string) (*jsonlFile, error) {
+	dir := filepath.Join(append([]string{fm.dir}, partition...)...)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	fm.seq++
+	ext := ".jsonl.gz"
+	if fm.compression == ZSTD {
+		ext = ".jsonl.zst"
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%v-%v-%04d%v", partition[0], now.UTC().Format("20060102T150405"), fm.seq, ext)
+	f := &jsonlFile{
+		path:    filepath.Join(dir, name),
+		tmpPath: filepath.Join(dir, "."+name+".tmp"),
+		created: now,
+	}
+	file, err := os.OpenFile(f.tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+	f.counter = &countingWriter{w: file}
+	if fm.compression == ZSTD {
+		f.enc, err = zstd.NewWriter(f.counter)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	} else {
+		f.enc = gzip.NewWriter(f.counter)
+	}
+	return f, nil
+}
+
+// run finalizes the files that have exceeded their maximum age
+func (fm *FileManager) run() {
+	defer close(fm.done)
+	interval := fm.maxAge / 10
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fm.quit:
+			return
+		case <-ticker.C:
+			fm.mu.Lock()
+			for key, f := range fm.files {
+				if time.Since(f.created) < fm.maxAge {
+					continue
+				}
+				delete(fm.files, key)
+				if err := f.finalize(); err != nil {
+					log.Errorf("Could not finalize %v: %v", f.path, err)
+				}
+			}
+			fm.mu.Unlock()
+		}
+	}
+}
+
+// jsonlFile is a compressed file that is being written to
+type jsonlFile struct {
+	path    string
+	tmpPath string
+	created time.Time
+	file    *os.File
+	counter *countingWriter
+	enc     io.WriteCloser
+}
+
+func (f *jsonlFile) Write(p []byte) (int, error) {
+	return f.enc.Write(p)
+}
+
+// size returns the number of compressed bytes written so far
+func (f *jsonlFile) size() int64 {
+	return f.counter.n
+}
+
+// finalize completes the compressed stream and makes the file visible under its final name
+func (f *jsonlFile) finalize() error {
+	if err := f.enc.Close(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.tmpPath, f.path); err != nil {
+		return err
+	}
+	log.Infof("Finalized %v", strings.TrimPrefix(f.path, "./"))
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	types "go-league-crawler/pkg/types/lol"
+	tft "go-league-crawler/pkg/types/tft"
+	"sort"
+	"sync"
+)
+
+// MemoryManager keeps everything it is given in memory, e.g. for tests and dry runs. It is safe for concurrent use.
+// Like the MongoManager, it stores every match only once and replaces players that are inserted again
+type MemoryManager struct {
+	// CrawlerVersion is stamped onto every stored document
+	CrawlerVersion string
+	mu             sync.RWMutex
+	matches        map[string]types.Match
+	players        map[string]types.Summoner
+	tftMatches     map[string]tft.Match
+	tftPlayers     map[string]types.Summoner
+	clashTeams     map[string]types.ClashTeam
+	challenges     []types.PlayerChallenges
+	raw            map[string]RawDocument
+}
+
+// NewMemoryManager returns an empty MemoryManager
+func NewMemoryManager() *MemoryManager {
+	mm := &MemoryManager{}
+	mm.Reset()
+	return mm
+}
+
+// Reset removes everything stored
+func (mm *MemoryManager) Reset() {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.matches = map[string]types.Match{}
+	mm.players = map[string]types.Summoner{}
+	mm.tftMatches = map[string]tft.Match{}
+	mm.tftPlayers = map[string]types.Summoner{}
+	mm.clashTeams = map[string]types.ClashTeam{}
+	mm.challenges = nil
+	mm.raw = map[string]RawDocument{}
+}
+
+func (mm *MemoryManager) InsertMatch(match types.Match) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if _, ok := mm.matches[match.MetaData.MatchID]; !ok {
+		match.SchemaVersion, match.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+		mm.matches[match.MetaData.MatchID] = match
+	}
+	return nil
+}
+
+func (mm *MemoryManager) InsertPlayer(player types.Summoner) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	mm.players[player.Puuid] = player
+	return nil
+}
+
+func (mm *MemoryManager) InsertTFTMatch(match tft.Match) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if _, ok := mm.tftMatches[match.MetaData.MatchID]; !ok {
+		match.SchemaVersion, match.CrawlerVersion = tft.SchemaVersion, mm.CrawlerVersion
+		mm.tftMatches[match.MetaData.MatchID] = match
+	}
+	return nil
+}
+
+func (mm *MemoryManager) InsertTFTPlayer(player types.Summoner) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	mm.tftPlayers[player.Puuid] = player
+	return nil
+}
+
+// InsertClashTeam stores a clash team, keeping the matches that have been linked to it before
+func (mm *MemoryManager) InsertClashTeam(team types.ClashTeam) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	team.Matches = mm.clashTeams[team.ID].Matches
+	team.SchemaVersion, team.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	mm.clashTeams[team.ID] = team
+	return nil
+}
+
+func (mm *MemoryManager) LinkClashMatch(teamID string, matchID string) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	team, ok := mm.clashTeams[teamID]
+	if !ok {
+		return nil
+	}
+	for _, id := range team.Matches {
+		if id == matchID {
+			return nil
+		}
+	}
+	team.Matches = append(team.Matches, matchID)
+	mm.clashTeams[teamID] = team
+	return nil
+}
+
+func (mm *MemoryManager) InsertPlayerChallenges(challenges types.PlayerChallenges) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	challenges.SchemaVersion, challenges.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	mm.challenges = append(mm.challenges, challenges)
+	return nil
+}
+
+func (mm *MemoryManager) InsertRaw(doc RawDocument) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.raw[doc.Kind+"/"+doc.ID] = doc
+	return nil
+}
+
+func (mm *MemoryManager) IterateRaw(kind string, fn func(RawDocument) error) error {
+	mm.mu.RLock()
+	docs := []RawDocument{}
+	for _, doc := range mm.raw {
+		if doc.Kind == kind {
+			docs = append(docs, doc)
+		}
+	}
+	mm.mu.RUnlock()
+	for _, doc := range docs {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mm *MemoryManager) IterateMatches(fn func(types.Match) error) error {
+	return mm.FindMatches(MatchQuery{}, fn)
+}
+
+func (mm *MemoryManager) GetMatch(matchID string) (types.Match, error) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	match, ok := mm.matches[matchID]
+	if !ok {
+		return match, ErrNotFound
+	}
+	return match, nil
+}
+
+func (mm *MemoryManager) MatchExists(matchID string) (bool, error) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	_, ok := mm.matches[matchID]
+	return ok, nil
+}
+
+// FindMatches calls fn for every stored match selected by query, ordered by creation, until fn returns an error.
+// fn may insert into the MemoryManager
+func (mm *MemoryManager) FindMatches(query MatchQuery, fn func(types.Match) error) error {
+	for _, match := range mm.Matches() {
+		if !query.Matches(&match) {
+			continue
+		}
+		if err := fn(match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mm *MemoryManager) CountMatches(query MatchQuery) (int64, error) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	var n int64
+	for _, match := range mm.matches {
+		if query.Matches(&match) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Matches returns the stored matches ordered by creation
+func (mm *MemoryManager) Matches() []types.Match {
+	mm.mu.RLock()
+	matches := make([]types.Match, 0, len(mm.matches))
+	for _, match := range mm.matches {
+		matches = append(matches, match)
+	}
+	mm.mu.RUnlock()
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Info.GameCreation != matches[j].Info.GameCreation {
+			return matches[i].Info.GameCreation < matches[j].Info.GameCreation
+		}
+		return matches[i].MetaData.MatchID < matches[j].MetaData.MatchID
+	})
+	return matches
+}
+
+// Player returns the stored player with the given puuid
+func (mm *MemoryManager) Player(puuid string) (types.Summoner, bool) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	player, ok := mm.players[puuid]
+	return player, ok
+}
+
+// Players returns the stored players ordered by puuid
+func (mm *MemoryManager) Players() []types.Summoner {
+	mm.mu.RLock()
+	players := make([]types.Summoner, 0, len(mm.players))
+	for _, player := range mm.players {
+		players = append(players, player)
+	}
+	mm.mu.RUnlock()
+	sort.Slice(players, func(i, j int) bool { return players[i].Puuid < players[j].Puuid })
+	return players
+}
+
+// ClashTeam returns the stored clash team with the given id
+func (mm *MemoryManager) ClashTeam(teamID string) (types.ClashTeam, bool) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	team, ok := mm.clashTeams[teamID]
+	return team, ok
+}
+
+// Challenges returns the stored challenge snapshots in the order they have been inserted
+func (mm *MemoryManager) Challenges() []types.PlayerChallenges {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	return append([]types.PlayerChallenges(nil), mm.challenges...)
+}
+
+// Counts returns the number of stored documents per collection, e.g. matches or tftPlayers
+func (mm *MemoryManager) Counts() map[string]int {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	return map[string]int{
+		MATCH_COLLECTION:      len(mm.matches),
+		PLAYER_COLLECTION:     len(mm.players),
+		TFT_MATCH_COLLECTION:  len(mm.tftMatches),
+		TFT_PLAYER_COLLECTION: len(mm.tftPlayers),
+		CLASH_TEAM_COLLECTION: len(mm.clashTeams),
+		CHALLENGE_COLLECTION:  len(mm.challenges),
+		RAW_COLLECTION:        len(mm.raw),
+	}
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DefaultMigrationBatchSize is the number of migrated documents written per bulk write
+const DefaultMigrationBatchSize = 500
+
+// Kinds of documents that are not archived raw, but migrated nonetheless
+const (
+	KIND_CLASH_TEAM = "clash-team"
+	KIND_CHALLENGES = "challenges"
+)
+
+// Migration upgrades a stored document of a kind from schema version From to From+1.
+// Documents without a schema version have been written before versioning and are considered to be of version 0
+type Migration struct {
+	Kind        string
+	From        int
+	Description string
+	Up          func(doc bson.M) (bson.M, error)
+}
+
+var migrations = map[string][]Migration{}
+
+// RegisterMigration adds a migration step. Steps of a kind need to be registered for consecutive versions
+func RegisterMigration(m Migration) {
+	migrations[m.Kind] = append(migrations[m.Kind], m)
+	sort.Slice(migrations[m.Kind], func(i, j int) bool {
+		return migrations[m.Kind][i].From < migrations[m.Kind][j].From
+	})
+}
+
+// Migrations returns the registered migration steps of a kind in the order they need to be applied
+func Migrations(kind string) []Migration {
+	return migrations[kind]
+}
+
+// MigrationKinds returns every kind for which migration steps have been registered
+func MigrationKinds() []string {
+	kinds := []string{}
+	for k := range migrations {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func init() {
+	RegisterMigration(Migration{
+		Kind:        KIND_MATCH,
+		From:        0,
+		Description: "Rename fields to their bson tags and replace the team objectives that have been lost by the empty objectives (reprocess the raw archive to recover them)",
+		Up: func(doc bson.M) (bson.M, error) {
+			doc = renameFields(doc, reflect.TypeOf(types.Match{}))
+			info, _ := doc["info"].(bson.M)
+			teams, _ := info["teams"].(bson.A)
+			for _, t := range teams {
+				team, ok := t.(bson.M)
+				if !ok {
+					continue
+				}
+				if objectives, ok := team["objectives"].(bson.M); ok {
+					if _, legacy := objectives["kills"]; legacy {
+						team["objectives"] = types.Objectives{}
+					}
+				}
+			}
+			return doc, nil
+		},
+	})
+	RegisterMigration(Migration{
+		Kind:        KIND_PLAYER,
+		From:        0,
+		Description: "Rename fields to their bson tags",
+		Up: func(doc bson.M) (bson.M, error) {
+			return renameFields(doc, reflect.TypeOf(types.Summoner{})), nil
+		},
+	})
+	RegisterMigration(Migration{
+		Kind:        KIND_TFT_PLAYER,
+		From:        0,
+		Description: "Rename fields to their bson tags",
+		Up: func(doc bson.M) (bson.M, error) {
+			return renameFields(doc, reflect.TypeOf(types.Summoner{})), nil
+		},
+	})
+	// The following kinds have been stored with bson tags from the start
+	for _, kind := range []string{KIND_TFT_MATCH, KIND_CLASH_TEAM, KIND_CHALLENGES} {
+		RegisterMigration(Migration{
+			Kind:        kind,
+			From:        0,
+			Description: "Stamp schema version",
+			Up: func(doc bson.M) (bson.M, error) {
+				return doc, nil
+			},
+		})
+	}
+}
+
+// renameFields renames the fields of a document, that have been stored under the default name of the driver
+// (the lowercased name of the struct field), to the bson tag of the corresponding struct field of t
+func renameFields(doc bson.M, t reflect.Type) bson.M {
+	renamed := bson.M{}
+	for k, v := range doc {
+		name, ft := k, reflect.Type(nil)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("bson"), ",")[0]
+			if tag == "" {
+				tag = strings.ToLower(f.Name)
+			}
+			if strings.EqualFold(k, tag) || strings.EqualFold(k, f.Name) {
+				name, ft = tag, f.Type
+				break
+			}
+		}
+		if ft != nil {
+			v = renameValue(v, ft)
+		}
+		renamed[name] = v
+	}
+	return renamed
+}
+
+func renameValue(v interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch val := v.(type) {
+	case bson.M:
+		if t.Kind() == reflect.Struct {
+			return renameFields(val, t)
+		}
+	case bson.A:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i := range val {
+				val[i] = renameValue(val[i], t.Elem())
+			}
+		}
+	}
+	return v
+}
+
+// migrationFilter selects the documents of the schema version a migration upgrades
+func migrationFilter(m Migration) bson.M {
+	if m.From == 0 {
+		return bson.M{"schemaVersion": bson.M{"$in": bson.A{nil, 0}}}
+	}
+	return bson.M{"schemaVersion": m.From}
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%v v%d -> v%d: %v", m.Kind, m.From, m.From+1, m.Description)
+}
@@ -2,64 +2,320 @@ package storage
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	types "go-league-crawler/pkg/types/lol"
-	"log"
+	tft "go-league-crawler/pkg/types/tft"
+	"io/ioutil"
+	"regexp"
+	"strconv"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	log "github.com/sirupsen/logrus"
 )
 
 const (
-	CONTEXT_TIMOUT    = 60 * time.Second
-	MATCH_COLLECTION  = "matches"
-	PLAYER_COLLECTION = "players"
+	CONTEXT_TIMOUT        = 60 * time.Second
+	MATCH_COLLECTION      = "matches"
+	PLAYER_COLLECTION     = "players"
+	CLASH_TEAM_COLLECTION = "clashTeams"
+	TFT_MATCH_COLLECTION  = "tftMatches"
+	TFT_PLAYER_COLLECTION = "tftPlayers"
+	CHALLENGE_COLLECTION  = "challenges"
+	RAW_COLLECTION        = "raw"
 )
 
 type MongoManager struct {
 	*DB
 	Client *mongo.Client
+	// Connection
+	uri          string
+	credential   *options.Credential
+	tlsConfig    *tls.Config
+	writeConcern *writeconcern.WriteConcern
+	maxPoolSize  uint64
+	// Batching of matches and players
+	batchSize     int
+	flushInterval time.Duration
+	writers       map[string]*BulkWriter
+	// OnWriteError is called with the documents a bulk writer could not write
+	OnWriteError func(coll string, models []mongo.WriteModel, err error)
+	// OnWrite is called with the documents a bulk writer has written, if it is not nil
+	OnWrite func(coll string, models []mongo.WriteModel)
+}
+
+type MongoOption func(*MongoManager) error
+
+// WithBatching makes the MongoManager write matches and players asynchronously in bulks of batchSize documents,
+// at the latest after flushInterval has passed
+func WithBatching(batchSize int, flushInterval time.Duration) func(*MongoManager) error {
+	return func(mm *MongoManager) error {
+		if batchSize < 1 || flushInterval <= 0 {
+			return fmt.Errorf("Batch Size and Flush Interval need to be positive (%v, %v)", batchSize, flushInterval)
+		}
+		mm.batchSize = batchSize
+		mm.flushInterval = flushInterval
+		return nil
+	}
 }
 
-// NewMManager returns a new MManager object in order to create a new mongo connection
-func NewMManager(host string, database string, matchCollection string, playerCollection string) *MongoManager {
+// WithURI connects to the given connection string instead of mongodb://<host>:27017,
+// e.g. to specify ports, replica sets or mongodb+srv:// uris
+func WithURI(uri string) func(*MongoManager) error {
+	return func(mm *MongoManager) error {
+		if err := options.Client().ApplyURI(uri).Validate(); err != nil {
+			return fmt.Errorf("Invalid MongoDB URI: %v", err)
+		}
+		mm.uri = uri
+		return nil
+	}
+}
+
+// mongoCredentials is the content of a credentials file
+type mongoCredentials struct {
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	AuthSource    string `json:"authSource"`
+	AuthMechanism string `json:"authMechanism"`
+}
+
+// WithCredentialsFile authenticates with the credentials of a json file of the form
+// {"username": "...", "password": "...", "authSource": "admin", "authMechanism": "SCRAM-SHA-256"},
+// which keeps the password out of the uri and the process list. authSource and authMechanism are optional
+func WithCredentialsFile(path string) func(*MongoManager) error {
+	return func(mm *MongoManager) error {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Could not read MongoDB credentials file: %v", err)
+		}
+		creds := mongoCredentials{}
+		if err := json.Unmarshal(content, &creds); err != nil {
+			return fmt.Errorf("Could not parse MongoDB credentials file %v: %v", path, err)
+		}
+		if creds.Username == "" {
+			return fmt.Errorf("MongoDB credentials file %v lacks a username", path)
+		}
+		mm.credential = &options.Credential{
+			Username:      creds.Username,
+			Password:      creds.Password,
+			PasswordSet:   creds.Password != "",
+			AuthSource:    creds.AuthSource,
+			AuthMechanism: creds.AuthMechanism,
+		}
+		return nil
+	}
+}
+
+// WithTLS encrypts the connection. caFile (PEM) is used to verify the server instead of the system's root CAs,
+// certFile and keyFile (PEM) hold the client certificate, e.g. for X.509 authentication.
+// Each of them may be empty, keyFile also in case certFile contains both the certificate and its key
+func WithTLS(caFile string, certFile string, keyFile string) func(*MongoManager) error {
+	return func(mm *MongoManager) error {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			ca, err := ioutil.ReadFile(caFile)
+			if err != nil {
+				return fmt.Errorf("Could not read TLS CA file: %v", err)
+			}
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM(ca) {
+				return fmt.Errorf("TLS CA file %v contains no PEM certificates", caFile)
+			}
+		}
+		if certFile != "" {
+			if keyFile == "" {
+				keyFile = certFile
+			}
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return fmt.Errorf("Could not load TLS client certificate: %v", err)
+			}
+			config.Certificates = []tls.Certificate{cert}
+		}
+		mm.tlsConfig = config
+		return nil
+	}
+}
+
+// WithWriteConcern sets the acknowledgement required for writes: w is "majority", a number of nodes or a tag set,
+// journal requires the writes to be journaled and timeout limits the time waited for the acknowledgement (0 waits indefinitely)
+func WithWriteConcern(w string, journal bool, timeout time.Duration) func(*MongoManager) error {
+	return func(mm *MongoManager) error {
+		opts := []writeconcern.Option{writeconcern.J(journal), writeconcern.WTimeout(timeout)}
+		if n, err := strconv.Atoi(w); err == nil {
+			if n < 0 {
+				return fmt.Errorf("Write Concern needs to be non-negative (%v)", n)
+			}
+			opts = append(opts, writeconcern.W(n))
+		} else if w == "majority" {
+			opts = append(opts, writeconcern.WMajority())
+		} else if w != "" {
+			opts = append(opts, writeconcern.WTagSet(w))
+		}
+		mm.writeConcern = writeconcern.New(opts...)
+		return nil
+	}
+}
+
+// WithMaxPoolSize limits the number of connections the client keeps open to each server
+func WithMaxPoolSize(size uint64) func(*MongoManager) error {
+	return func(mm *MongoManager) error {
+		if size < 1 {
+			return fmt.Errorf("Pool Size needs to be positive (%v)", size)
+		}
+		mm.maxPoolSize = size
+		return nil
+	}
+}
+
+// NewMManager returns a new MManager object. The connection to mongo is established by Init
+func NewMManager(host string, database string, matchCollection string, playerCollection string, opts ...MongoOption) (*MongoManager, error) {
 	db := &DB{
-		Host:          host,
-		Database:      database,
-		MatchStorage:  matchCollection,
-		PlayerStorage: playerCollection,
+		Host:             host,
+		Database:         database,
+		MatchStorage:     matchCollection,
+		PlayerStorage:    playerCollection,
+		ClashTeamStorage: CLASH_TEAM_COLLECTION,
+		TFTMatchStorage:  TFT_MATCH_COLLECTION,
+		TFTPlayerStorage: TFT_PLAYER_COLLECTION,
+		ChallengeStorage: CHALLENGE_COLLECTION,
+		RawStorage:       RAW_COLLECTION,
 	}
 	mm := &MongoManager{
-		DB: db,
+		DB:      db,
+		writers: make(map[string]*BulkWriter),
+	}
+	for _, opt := range opts {
+		if err := opt(mm); err != nil {
+			return mm, err
+		}
 	}
+	return mm, nil
+}
+
+// Connect connects to the database without touching its collections
+func (mm *MongoManager) Connect() error {
 	client, err := mm.connect()
 	if err != nil {
-		fmt.Print(err)
+		return err
 	}
 	mm.Client = client
-	return mm
+	return mm.ping()
 }
 
+// Init connects to the database and prepares its collections. Documents of previous schema versions are migrated first,
+// since the unique indexes refer to the current field names, e.g. metaData.matchId instead of the legacy metaData.matchid
 func (mm *MongoManager) Init() error {
-	client, err := mm.connect()
-	mm.Client = client
-	err = mm.ping()
+	if err := mm.Connect(); err != nil {
+		return err
+	}
+	for _, kind := range MigrationKinds() {
+		if _, err := mm.Migrate(kind, DefaultMigrationBatchSize); err != nil {
+			return fmt.Errorf("Could not migrate documents of kind %v: %v", kind, err)
+		}
+	}
+	if err := mm.createIndexes(); err != nil {
+		return err
+	}
+	if mm.batchSize > 0 {
+		for _, collection := range []string{mm.MatchStorage, mm.PlayerStorage, mm.TFTMatchStorage, mm.TFTPlayerStorage} {
+			w := NewBulkWriter(mm.Client.Database(mm.Database).Collection(collection), mm.batchSize, mm.flushInterval)
+			if mm.OnWriteError != nil {
+				w.OnError = mm.OnWriteError
+			}
+			w.OnWrite = mm.OnWrite
+			mm.writers[collection] = w
+		}
+	}
+	return nil
+}
+
+// Close writes the documents that are still pending and disconnects from the database
+func (mm *MongoManager) Close() error {
+	for collection, w := range mm.writers {
+		w.Close()
+		delete(mm.writers, collection)
+	}
+	if mm.Client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+	defer cancel()
+	err := mm.Client.Disconnect(ctx)
+	mm.Client = nil
 	return err
 }
 
+// createIndexes ensures that every document is stored at most once, so that inserts can be upserts
+func (mm *MongoManager) createIndexes() error {
+	unique := map[string]bson.D{
+		mm.MatchStorage:     {{Key: "metaData.matchId", Value: 1}},
+		mm.PlayerStorage:    {{Key: "puuid", Value: 1}},
+		mm.TFTMatchStorage:  {{Key: "metadata.match_id", Value: 1}},
+		mm.TFTPlayerStorage: {{Key: "puuid", Value: 1}},
+		mm.ClashTeamStorage: {{Key: "id", Value: 1}},
+		mm.RawStorage:       {{Key: "kind", Value: 1}, {Key: "id", Value: 1}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+	defer cancel()
+	for collection, keys := range unique {
+		model := mongo.IndexModel{
+			Keys:    keys,
+			Options: options.Index().SetUnique(true),
+		}
+		_, err := mm.Client.Database(mm.Database).Collection(collection).Indexes().CreateOne(ctx, model)
+		if err != nil {
+			return fmt.Errorf("Could not create unique index on %v (remove duplicate documents first): %v", collection, err)
+		}
+	}
+	return nil
+}
+
+// URI returns the connection string the MongoManager connects to
+func (mm *MongoManager) URI() string {
+	if mm.uri != "" {
+		return mm.uri
+	}
+	return fmt.Sprintf("mongodb://%v:27017", mm.Host)
+}
+
+// clientOptions combines the uri with the connection settings given as options, the latter taking precedence
+func (mm *MongoManager) clientOptions() *options.ClientOptions {
+	opts := options.Client().ApplyURI(mm.URI())
+	if mm.credential != nil {
+		opts.SetAuth(*mm.credential)
+	}
+	if mm.tlsConfig != nil {
+		opts.SetTLSConfig(mm.tlsConfig)
+	}
+	if mm.writeConcern != nil {
+		opts.SetWriteConcern(mm.writeConcern)
+	}
+	if mm.maxPoolSize > 0 {
+		opts.SetMaxPoolSize(mm.maxPoolSize)
+	}
+	return opts
+}
+
 func (mm *MongoManager) connect() (*mongo.Client, error) {
-	client, err := mongo.NewClient(options.Client().ApplyURI(fmt.Sprintf("mongodb://%v:27017", mm.Host)))
+	client, err := mongo.NewClient(mm.clientOptions())
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("Could not create MongoDB client: %v", err)
 	}
-	ctx, _ := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
-	err = client.Connect(ctx)
-	if err != nil {
-		log.Fatal(err)
+	ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("Could not connect to MongoDB: %v", err)
 	}
-	return client, err
+	return client, nil
 }
 
 // Ping Tests the Client Connection
@@ -69,14 +325,374 @@ func (mm *MongoManager) ping() error {
 	return mm.Client.Ping(ctx, readpref.Primary())
 }
 
+// InsertMatch stores a match unless it has already been stored
 func (mm *MongoManager) InsertMatch(match types.Match) error {
-	res, err := mm.Client.Database(mm.Database).Collection(mm.MatchStorage).InsertOne(context.TODO(), match)
-	fmt.Println(fmt.Sprintf("Stored Match with ID: %v\n", res.InsertedID))
-	return err
+	match.SchemaVersion, match.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	filter := bson.M{"metaData.matchId": match.MetaData.MatchID}
+	id, err := mm.write(mm.MatchStorage, insertOnceModel(filter, match))
+	if err != nil {
+		return err
+	}
+	log.Debugf("Stored Match with ID: %v", id)
+	return nil
 }
 
+// InsertPlayer stores a player or updates the stored player with the same puuid,
+// keeping track of the names the player has been observed with
 func (mm *MongoManager) InsertPlayer(player types.Summoner) error {
-	res, err := mm.Client.Database(mm.Database).Collection(mm.PlayerStorage).InsertOne(context.TODO(), player)
-	fmt.Printf("Stored Player with ID: %v\n", res.InsertedID)
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	model, err := upsertPlayerModel(player)
+	if err != nil {
+		return err
+	}
+	id, err := mm.write(mm.PlayerStorage, model)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Stored Player with ID: %v", id)
+	return nil
+}
+
+// InsertTFTMatch stores a tft match unless it has already been stored
+func (mm *MongoManager) InsertTFTMatch(match tft.Match) error {
+	match.SchemaVersion, match.CrawlerVersion = tft.SchemaVersion, mm.CrawlerVersion
+	filter := bson.M{"metadata.match_id": match.MetaData.MatchID}
+	id, err := mm.write(mm.TFTMatchStorage, insertOnceModel(filter, match))
+	if err != nil {
+		return err
+	}
+	log.Debugf("Stored TFT Match with ID: %v", id)
+	return nil
+}
+
+// InsertTFTPlayer stores a tft player or updates the stored tft player with the same puuid,
+// keeping track of the names the player has been observed with
+func (mm *MongoManager) InsertTFTPlayer(player types.Summoner) error {
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	model, err := upsertPlayerModel(player)
+	if err != nil {
+		return err
+	}
+	id, err := mm.write(mm.TFTPlayerStorage, model)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Stored TFT Player with ID: %v", id)
+	return nil
+}
+
+// write executes a model against a collection, asynchronously in case batching is enabled.
+// The id of the stored document is only returned if it has been inserted synchronously
+func (mm *MongoManager) write(collection string, model mongo.WriteModel) (interface{}, error) {
+	if w, ok := mm.writers[collection]; ok {
+		w.Write(model)
+		return nil, nil
+	}
+	if mm.Client == nil {
+		return nil, fmt.Errorf("Not connected to MongoDB")
+	}
+	coll := mm.Client.Database(mm.Database).Collection(collection)
+	ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+	defer cancel()
+	res, err := coll.BulkWrite(ctx, []mongo.WriteModel{model})
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert of the same document won, thus the document can be updated now
+		res, err = coll.BulkWrite(ctx, []mongo.WriteModel{model})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.UpsertedIDs[0], nil
+}
+
+// insertOnceModel inserts a document unless a document matching the filter has already been stored
+func insertOnceModel(filter bson.M, doc interface{}) mongo.WriteModel {
+	return mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.M{"$setOnInsert": doc}).SetUpsert(true)
+}
+
+// upsertPlayerModel updates the stored player with the same puuid or inserts it, appending
+// its name to the name history in case it differs from the most recently observed one
+func upsertPlayerModel(player types.Summoner) (mongo.WriteModel, error) {
+	player.NameHistory = nil
+	raw, err := bson.Marshal(player)
+	if err != nil {
+		return nil, err
+	}
+	fields := bson.M{}
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	// Values of an update pipeline are expressions, i.e. a name like "$abc" would refer to a field otherwise
+	for k, v := range fields {
+		fields[k] = bson.M{"$literal": v}
+	}
+	history := bson.M{"$ifNull": bson.A{"$nameHistory", bson.A{}}}
+	change := types.NameChange{
+		Name:       player.Name,
+		ObservedAt: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: fields}},
+		{{Key: "$set", Value: bson.M{
+			"nameHistory": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{bson.M{"$arrayElemAt": bson.A{"$nameHistory.name", -1}}, bson.M{"$literal": change.Name}}},
+				history,
+				bson.M{"$concatArrays": bson.A{history, bson.A{bson.M{"$literal": change}}}},
+			}},
+		}}},
+	}
+	return mongo.NewUpdateOneModel().SetFilter(bson.M{"puuid": player.Puuid}).SetUpdate(pipeline).SetUpsert(true), nil
+}
+
+// InsertPlayerChallenges stores a snapshot of the challenge progress of a player.
+// Snapshots are never overwritten, so that the progress can be tracked over time
+func (mm *MongoManager) InsertPlayerChallenges(challenges types.PlayerChallenges) error {
+	challenges.SchemaVersion, challenges.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	res, err := mm.Client.Database(mm.Database).Collection(mm.ChallengeStorage).InsertOne(context.TODO(), challenges)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Stored Challenges with ID: %v", res.InsertedID)
+	return nil
+}
+
+// InsertClashTeam stores a clash team, updating it in case it has already been stored before
+// while keeping the matches that have been linked to it
+func (mm *MongoManager) InsertClashTeam(team types.ClashTeam) error {
+	team.Matches = nil
+	team.SchemaVersion, team.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	opts := options.Update().SetUpsert(true)
+	_, err := mm.Client.Database(mm.Database).Collection(mm.ClashTeamStorage).UpdateOne(context.TODO(), bson.M{"id": team.ID}, bson.M{"$set": team}, opts)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Stored Clash Team with ID: %v", team.ID)
+	return nil
+}
+
+// LinkClashMatch adds a match to the matches played by a clash team
+func (mm *MongoManager) LinkClashMatch(teamID string, matchID string) error {
+	_, err := mm.Client.Database(mm.Database).Collection(mm.ClashTeamStorage).UpdateOne(context.TODO(), bson.M{"id": teamID}, bson.M{"$addToSet": bson.M{"matches": matchID}})
 	return err
 }
+
+// InsertRaw stores a raw api response, replacing a previously stored response of the same document
+func (mm *MongoManager) InsertRaw(doc RawDocument) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := mm.Client.Database(mm.Database).Collection(mm.RawStorage).ReplaceOne(context.TODO(), bson.M{"kind": doc.Kind, "id": doc.ID}, doc, opts)
+	return err
+}
+
+func (mm *MongoManager) IterateRaw(kind string, fn func(RawDocument) error) error {
+	ctx := context.TODO()
+	cur, err := mm.Client.Database(mm.Database).Collection(mm.RawStorage).Find(ctx, bson.M{"kind": kind})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		doc := RawDocument{}
+		if err := cur.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// IterateMatches calls fn for every stored match until fn returns an error
+func (mm *MongoManager) IterateMatches(fn func(types.Match) error) error {
+	return mm.FindMatches(MatchQuery{}, fn)
+}
+
+// GetMatch returns the stored match with the given id. Matches still waiting for their bulk to be written are not found
+func (mm *MongoManager) GetMatch(matchID string) (types.Match, error) {
+	match := types.Match{}
+	ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+	defer cancel()
+	err := mm.Client.Database(mm.Database).Collection(mm.MatchStorage).FindOne(ctx, bson.M{"metaData.matchId": matchID}).Decode(&match)
+	if err == mongo.ErrNoDocuments {
+		return match, ErrNotFound
+	}
+	return match, err
+}
+
+func (mm *MongoManager) MatchExists(matchID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+	defer cancel()
+	n, err := mm.Client.Database(mm.Database).Collection(mm.MatchStorage).CountDocuments(ctx, bson.M{"metaData.matchId": matchID}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// FindMatches calls fn for every stored match selected by query, ordered by creation, until fn returns an error
+func (mm *MongoManager) FindMatches(query MatchQuery, fn func(types.Match) error) error {
+	ctx := context.TODO()
+	opts := options.Find().SetSort(bson.D{{Key: "info.gameCreation", Value: 1}})
+	cur, err := mm.Client.Database(mm.Database).Collection(mm.MatchStorage).Find(ctx, matchFilter(query), opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		match := types.Match{}
+		if err := cur.Decode(&match); err != nil {
+			return err
+		}
+		if err := fn(match); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func (mm *MongoManager) CountMatches(query MatchQuery) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CONTEXT_TIMOUT)
+	defer cancel()
+	return mm.Client.Database(mm.Database).Collection(mm.MatchStorage).CountDocuments(ctx, matchFilter(query))
+}
+
+// matchFilter translates a MatchQuery into a filter on the match collection
+func matchFilter(query MatchQuery) bson.M {
+	filter := bson.M{}
+	if query.Puuid != "" {
+		filter["metaData.participants"] = query.Puuid
+	}
+	if query.QueueID != 0 {
+		filter["info.queueId"] = query.QueueID
+	}
+	if query.Patch != "" {
+		filter["info.gameVersion"] = bson.M{"$regex": "^" + regexp.QuoteMeta(query.Patch) + `\.`}
+	}
+	created := bson.M{}
+	if !query.Since.IsZero() {
+		created["$gte"] = query.Since.UnixNano() / int64(time.Millisecond)
+	}
+	if !query.Until.IsZero() {
+		created["$lt"] = query.Until.UnixNano() / int64(time.Millisecond)
+	}
+	if len(created) > 0 {
+		filter["info.gameCreation"] = created
+	}
+	return filter
+}
+
+// ReplaceMatch stores a match, replacing a previously stored match with the same id
+func (mm *MongoManager) ReplaceMatch(match types.Match) error {
+	match.SchemaVersion, match.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	return mm.replace(mm.MatchStorage, bson.M{"metaData.matchId": match.MetaData.MatchID}, match)
+}
+
+// ReplacePlayer stores a player, replacing the fields of a previously stored player with the same puuid
+func (mm *MongoManager) ReplacePlayer(player types.Summoner) error {
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	model, err := upsertPlayerModel(player)
+	if err != nil {
+		return err
+	}
+	_, err = mm.write(mm.PlayerStorage, model)
+	return err
+}
+
+// ReplaceTFTMatch stores a tft match, replacing a previously stored tft match with the same id
+func (mm *MongoManager) ReplaceTFTMatch(match tft.Match) error {
+	match.SchemaVersion, match.CrawlerVersion = tft.SchemaVersion, mm.CrawlerVersion
+	return mm.replace(mm.TFTMatchStorage, bson.M{"metadata.match_id": match.MetaData.MatchID}, match)
+}
+
+// ReplaceTFTPlayer stores a tft player, replacing the fields of a previously stored tft player with the same puuid
+func (mm *MongoManager) ReplaceTFTPlayer(player types.Summoner) error {
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, mm.CrawlerVersion
+	model, err := upsertPlayerModel(player)
+	if err != nil {
+		return err
+	}
+	_, err = mm.write(mm.TFTPlayerStorage, model)
+	return err
+}
+
+func (mm *MongoManager) replace(collection string, filter bson.M, doc interface{}) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := mm.Client.Database(mm.Database).Collection(collection).ReplaceOne(context.TODO(), filter, doc, opts)
+	return err
+}
+
+// Migrate upgrades the stored documents of a kind in place by applying the registered migration steps in bulk.
+// It returns the number of documents that have been upgraded by a step
+func (mm *MongoManager) Migrate(kind string, batchSize int) (int, error) {
+	coll, err := mm.collection(kind)
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.TODO()
+	total := 0
+	for _, m := range Migrations(kind) {
+		cur, err := coll.Find(ctx, migrationFilter(m))
+		if err != nil {
+			return total, err
+		}
+		models := []mongo.WriteModel{}
+		flush := func() error {
+			if len(models) == 0 {
+				return nil
+			}
+			_, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+			models = models[:0]
+			return err
+		}
+		n := 0
+		for cur.Next(ctx) {
+			doc := bson.M{}
+			if err = cur.Decode(&doc); err != nil {
+				break
+			}
+			id := doc["_id"]
+			if doc, err = m.Up(doc); err != nil {
+				err = fmt.Errorf("%v: document %v: %v", m, id, err)
+				break
+			}
+			doc["_id"] = id
+			doc["schemaVersion"] = m.From + 1
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": id}).SetReplacement(doc))
+			n++
+			if len(models) >= batchSize {
+				if err = flush(); err != nil {
+					break
+				}
+			}
+		}
+		if err == nil {
+			err = cur.Err()
+		}
+		if err == nil {
+			err = flush()
+		}
+		cur.Close(ctx)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n > 0 {
+			log.Infof("Migrated %d documents: %v", n, m)
+		}
+	}
+	return total, nil
+}
+
+// collection returns the collection storing the documents of a kind
+func (mm *MongoManager) collection(kind string) (*mongo.Collection, error) {
+	names := map[string]string{
+		KIND_MATCH:      mm.MatchStorage,
+		KIND_PLAYER:     mm.PlayerStorage,
+		KIND_TFT_MATCH:  mm.TFTMatchStorage,
+		KIND_TFT_PLAYER: mm.TFTPlayerStorage,
+		KIND_CLASH_TEAM: mm.ClashTeamStorage,
+		KIND_CHALLENGES: mm.ChallengeStorage,
+	}
+	name, ok := names[kind]
+	if !ok {
+		return nil, fmt.Errorf("Unknown kind %v", kind)
+	}
+	return mm.Client.Database(mm.Database).Collection(name), nil
+}
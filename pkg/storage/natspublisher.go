@@ -0,0 +1,452 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Delivery guarantees of a NATSPublisher
+const (
+	// AT_MOST_ONCE hands messages to the connection without waiting for the broker
+	AT_MOST_ONCE = "at-most-once"
+	// AT_LEAST_ONCE waits for the broker to have processed each message and keeps it in the outbox otherwise
+	AT_LEAST_ONCE = "at-least-once"
+)
+
+const (
+	DefaultMatchSubject    = "lol.matches"
+	DefaultPlayerSubject   = "lol.players"
+	DefaultNATSTimeout     = 5 * time.Second
+	DefaultNATSReconnectIn = 5 * time.Second
+)
+
+// Message is a keyed message as published to the broker and kept in the outbox
+type Message struct {
+	Subject string `json:"subject"`
+	Key     string `json:"key"`
+	Data    []byte `json:"data"`
+}
+
+// Rejection is a message that cannot be delivered, as kept in the reject file next to the outbox:
+// either a message the broker rejected or a line of the outbox that could not be decoded
+type Rejection struct {
+	Reason  string   `json:"reason"`
+	Message *Message `json:"message,omitempty"`
+	Line    string   `json:"line,omitempty"`
+}
+
+// RejectedError is returned for messages the broker answered with -ERR, which would be rejected again when retried
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "Rejected by NATS broker: " + e.Reason
+}
+
+// NATSPublisher publishes every match and player as a message to a NATS broker, speaking the client protocol directly.
+// The key of a message (match id or puuid) is sent as the headers Key and Nats-Msg-Id, the latter letting JetStream
+// streams drop messages that have been delivered twice. Messages that cannot be delivered while the broker is unavailable
+// are appended to a local outbox file, which is replayed as soon as the broker is reachable again.
+// Messages the broker rejects are moved to a reject file instead (see RejectFile), so that they do not block the outbox
+type NATSPublisher struct {
+	addr          string
+	matchSubject  string
+	playerSubject string
+	delivery      string
+	outbox        string
+	timeout       time.Duration
+	reconnectIn   time.Duration
+	// CrawlerVersion is stamped onto every published match and player
+	CrawlerVersion string
+	mu             sync.Mutex
+	conn           net.Conn
+	r              *bufio.Reader
+	lastAttempt    time.Time
+}
+
+type NATSOption func(*NATSPublisher) error
+
+// WithSubjects sets the subjects (topics) matches and players are published to
+func WithSubjects(matchSubject string, playerSubject string) func(*NATSPublisher) error {
+	return func(np *NATSPublisher) error {
+		if matchSubject == "" || playerSubject == "" {
+			return fmt.Errorf("Subjects must not be empty")
+		}
+		np.matchSubject, np.playerSubject = matchSubject, playerSubject
+		return nil
+	}
+}
+
+// WithDelivery sets the delivery guarantee, AT_LEAST_ONCE (default) or AT_MOST_ONCE
+func WithDelivery(delivery string) func(*NATSPublisher) error {
+	return func(np *NATSPublisher) error {
+		if delivery != AT_LEAST_ONCE && delivery != AT_MOST_ONCE {
+			return fmt.Errorf("Unknown delivery guarantee %v (%v or %v)", delivery, AT_LEAST_ONCE, AT_MOST_ONCE)
+		}
+		np.delivery = delivery
+		return nil
+	}
+}
+
+// WithOutbox sets the file undeliverable messages are kept in
+func WithOutbox(path string) func(*NATSPublisher) error {
+	return func(np *NATSPublisher) error {
+		np.outbox = path
+		return nil
+	}
+}
+
+// WithNATSTimeouts sets how long to wait for the broker and how long to wait before reconnecting after it has been unavailable
+func WithNATSTimeouts(timeout time.Duration, reconnectIn time.Duration) func(*NATSPublisher) error {
+	return func(np *NATSPublisher) error {
+		if timeout <= 0 || reconnectIn < 0 {
+			return fmt.Errorf("Invalid timeouts (%v, %v)", timeout, reconnectIn)
+		}
+		np.timeout, np.reconnectIn = timeout, reconnectIn
+		return nil
+	}
+}
+
+// NewNATSPublisher returns a NATSPublisher publishing to the broker at addr (host:port)
+func NewNATSPublisher(addr string, opts ...NATSOption) (*NATSPublisher, error) {
+	np := &NATSPublisher{
+		addr:          addr,
+		matchSubject:  DefaultMatchSubject,
+		playerSubject: DefaultPlayerSubject,
+		delivery:      AT_LEAST_ONCE,
+		outbox:        "./outbox.jsonl",
+		timeout:       DefaultNATSTimeout,
+		reconnectIn:   DefaultNATSReconnectIn,
+	}
+	for _, opt := range opts {
+		if err := opt(np); err != nil {
+			return np, err
+		}
+	}
+	return np, nil
+}
+
+// Init connects to the broker and delivers the messages left in the outbox.
+// An unavailable broker is no error, messages are kept in the outbox until it is available
+func (np *NATSPublisher) Init() error {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if err := np.ensureConnected(); err != nil {
+		log.Warnf("NATS broker %v is unavailable, keeping messages in %v: %v", np.addr, np.outbox, err)
+	}
+	return nil
+}
+
+// Close closes the connection to the broker
+func (np *NATSPublisher) Close() error {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if np.conn == nil {
+		return nil
+	}
+	err := np.conn.Close()
+	np.conn = nil
+	return err
+}
+
+// InsertMatch publishes a match keyed by its id
+func (np *NATSPublisher) InsertMatch(match types.Match) error {
+	match.SchemaVersion, match.CrawlerVersion = types.SchemaVersion, np.CrawlerVersion
+	data, err := json.Marshal(match)
+	if err != nil {
+		return err
+	}
+	return np.Publish(Message{Subject: np.matchSubject, Key: match.MetaData.MatchID, Data: data})
+}
+
+// InsertPlayer publishes a player keyed by its puuid
+func (np *NATSPublisher) InsertPlayer(player types.Summoner) error {
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, np.CrawlerVersion
+	data, err := json.Marshal(player)
+	if err != nil {
+		return err
+	}
+	return np.Publish(Message{Subject: np.playerSubject, Key: player.Puuid, Data: data})
+}
+
+// RejectFile returns the file the messages rejected by the broker are kept in, e.g. outbox.rejected.jsonl for outbox.jsonl
+func (np *NATSPublisher) RejectFile() string {
+	ext := filepath.Ext(np.outbox)
+	return strings.TrimSuffix(np.outbox, ext) + ".rejected" + ext
+}
+
+// Publish delivers a message to the broker, or appends it to the outbox if the broker is unavailable
+// and to the reject file if the broker rejected it. Only failing to keep the message in either file is an error
+func (np *NATSPublisher) Publish(msg Message) error {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	if err := np.ensureConnected(); err != nil {
+		return np.keep(msg)
+	}
+	err := np.deliver([]Message{msg})
+	var rejected *RejectedError
+	if errors.As(err, &rejected) {
+		log.Errorf("NATS broker rejected %v to %v, keeping it in %v: %v", msg.Key, msg.Subject, np.RejectFile(), rejected.Reason)
+		return np.reject(Rejection{Reason: rejected.Reason, Message: &msg})
+	}
+	if err != nil {
+		log.Warnf("Could not publish %v to %v, keeping it in %v: %v", msg.Key, msg.Subject, np.outbox, err)
+		np.disconnect()
+		return np.keep(msg)
+	}
+	return nil
+}
+
+// ensureConnected connects to the broker unless connected, and replays the outbox. The caller needs to hold the lock
+func (np *NATSPublisher) ensureConnected() error {
+	if np.conn != nil {
+		return nil
+	}
+	if time.Since(np.lastAttempt) < np.reconnectIn {
+		return fmt.Errorf("Waiting to reconnect")
+	}
+	np.lastAttempt = time.Now()
+	if err := np.connect(); err != nil {
+		return err
+	}
+	if err := np.replay(); err != nil {
+		np.disconnect()
+		return err
+	}
+	return nil
+}
+
+// connect dials the broker and introduces the client. The caller needs to hold the lock
+func (np *NATSPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", np.addr, np.timeout)
+	if err != nil {
+		return err
+	}
+	np.conn, np.r = conn, bufio.NewReader(conn)
+	if err := np.handshake(); err != nil {
+		np.disconnect()
+		return err
+	}
+	return nil
+}
+
+// handshake reads the INFO of the broker, introduces the client and waits until the broker has accepted it
+func (np *NATSPublisher) handshake() error {
+	np.conn.SetDeadline(time.Now().Add(np.timeout))
+	defer np.conn.SetDeadline(time.Time{})
+	line, err := np.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		return fmt.Errorf("Unexpected greeting of NATS broker: %q", line)
+	}
+	connect := `CONNECT {"verbose":false,"pedantic":false,"headers":true,"no_responders":false,"name":"go-league-crawler","lang":"go","version":"1"}`
+	if _, err := io.WriteString(np.conn, connect+"\r\nPING\r\n"); err != nil {
+		return err
+	}
+	return np.awaitPong()
+}
+
+// deliver writes messages to the connection and, for AT_LEAST_ONCE, waits until the broker has processed them
+func (np *NATSPublisher) deliver(msgs []Message) error {
+	np.conn.SetDeadline(time.Now().Add(np.timeout))
+	defer np.conn.SetDeadline(time.Time{})
+	w := bufio.NewWriter(np.conn)
+	for _, msg := range msgs {
+		headers := fmt.Sprintf("NATS/1.0\r\nKey: %v\r\nNats-Msg-Id: %v\r\n\r\n", msg.Key, msg.Key)
+		fmt.Fprintf(w, "HPUB %v %d %d\r\n%v", msg.Subject, len(headers), len(headers)+len(msg.Data), headers)
+		w.Write(msg.Data)
+		w.WriteString("\r\n")
+	}
+	if np.delivery == AT_MOST_ONCE {
+		return w.Flush()
+	}
+	w.WriteString("PING\r\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return np.awaitPong()
+}
+
+// awaitPong reads until the broker answers a PING. Since brokers process the messages of a connection in order,
+// all messages published before the PING have been processed by then. A -ERR of the broker is returned as RejectedError
+// once the PONG has been read, or as soon as the broker closed the connection
+func (np *NATSPublisher) awaitPong() error {
+	var rejected error
+	for {
+		line, err := np.readLine()
+		if err != nil {
+			if rejected != nil {
+				np.disconnect()
+				return rejected
+			}
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return rejected
+		case line == "PING":
+			if _, err := io.WriteString(np.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			rejected = &RejectedError{Reason: strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'")}
+		}
+	}
+}
+
+func (np *NATSPublisher) readLine() (string, error) {
+	line, err := np.r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (np *NATSPublisher) disconnect() {
+	if np.conn != nil {
+		np.conn.Close()
+	}
+	np.conn, np.r = nil, nil
+}
+
+// keep appends a message to the outbox
+func (np *NATSPublisher) keep(msg Message) error {
+	f, err := os.OpenFile(np.outbox, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Could not keep %v in outbox: %v", msg.Key, err)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(msg)
+}
+
+// reject appends a rejection to the reject file
+func (np *NATSPublisher) reject(rejection Rejection) error {
+	f, err := os.OpenFile(np.RejectFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Could not keep rejected message in %v: %v", np.RejectFile(), err)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(rejection)
+}
+
+// replay delivers the messages of the outbox and removes it once they have been delivered. Lines that cannot be decoded
+// and messages the broker rejects are moved to the reject file. If the broker becomes unavailable meanwhile,
+// the outbox is truncated to the messages that have not been delivered yet
+func (np *NATSPublisher) replay() error {
+	msgs, err := np.readOutbox()
+	if err != nil || msgs == nil {
+		return err
+	}
+	delivered := 0
+	for delivered < len(msgs) {
+		end := delivered + 100
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+		n, err := np.deliverBatch(msgs[delivered:end])
+		delivered += n
+		if err != nil {
+			if kerr := np.rewriteOutbox(msgs[delivered:]); kerr != nil {
+				log.Errorf("Could not truncate outbox %v: %v", np.outbox, kerr)
+			}
+			return err
+		}
+	}
+	if len(msgs) > 0 {
+		log.Infof("Delivered %d messages of outbox %v", len(msgs), np.outbox)
+	}
+	return os.Remove(np.outbox)
+}
+
+// readOutbox returns the messages of the outbox, or nil if there is none. Lines that cannot be decoded are moved to the reject file
+func (np *NATSPublisher) readOutbox() ([]Message, error) {
+	f, err := os.Open(np.outbox)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	msgs := []Message{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		msg := Message{}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Errorf("Moving corrupt line of outbox %v to %v: %v", np.outbox, np.RejectFile(), err)
+			if err := np.reject(Rejection{Reason: err.Error(), Line: scanner.Text()}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, scanner.Err()
+}
+
+// deliverBatch delivers messages of the outbox and returns how many of them have been processed. In case the broker
+// rejected the batch, its messages are delivered one by one to move the rejected ones to the reject file,
+// reconnecting whenever the broker closed the connection after a rejection
+func (np *NATSPublisher) deliverBatch(msgs []Message) (int, error) {
+	err := np.deliver(msgs)
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) {
+		if err != nil {
+			return 0, err
+		}
+		return len(msgs), nil
+	}
+	for i, msg := range msgs {
+		if np.conn == nil {
+			if err := np.connect(); err != nil {
+				return i, err
+			}
+		}
+		err := np.deliver([]Message{msg})
+		if !errors.As(err, &rejected) {
+			if err != nil {
+				return i, err
+			}
+			continue
+		}
+		log.Errorf("NATS broker rejected %v to %v of outbox %v: %v", msg.Key, msg.Subject, np.outbox, rejected.Reason)
+		msg := msg
+		if err := np.reject(Rejection{Reason: rejected.Reason, Message: &msg}); err != nil {
+			return i, err
+		}
+	}
+	return len(msgs), nil
+}
+
+// rewriteOutbox replaces the outbox by the given messages
+func (np *NATSPublisher) rewriteOutbox(msgs []Message) error {
+	tmp := filepath.Join(filepath.Dir(np.outbox), "."+filepath.Base(np.outbox)+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, np.outbox)
+}
@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const (
+	DefaultParquetMaxRows = 1000000
+	DefaultParquetMaxAge  = time.Hour
+)
+
+// ParticipantRow is the flattened view of a participant of a match, carrying the columns of the match,
+// the objectives of the participant's team and the participant's runes
+type ParticipantRow struct {
+	// Match
+	MatchID      string `parquet:"name=match_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PlatformID   string `parquet:"name=platform_id, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	GameVersion  string `parquet:"name=game_version, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Patch        string `parquet:"name=patch, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	QueueID      int32  `parquet:"name=queue_id, type=INT32"`
+	GameCreation int64  `parquet:"name=game_creation, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	GameDuration int64  `parquet:"name=game_duration, type=INT64"`
+	// Participant
+	Puuid                       string `parquet:"name=puuid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ParticipantID               int32  `parquet:"name=participant_id, type=INT32"`
+	TeamID                      int32  `parquet:"name=team_id, type=INT32"`
+	ChampionID                  int32  `parquet:"name=champion_id, type=INT32"`
+	ChampionName                string `parquet:"name=champion_name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	ChampLevel                  int32  `parquet:"name=champ_level, type=INT32"`
+	TeamPosition                string `parquet:"name=team_position, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Win                         bool   `parquet:"name=win, type=BOOLEAN"`
+	Kills                       int32  `parquet:"name=kills, type=INT32"`
+	Deaths                      int32  `parquet:"name=deaths, type=INT32"`
+	Assists                     int32  `parquet:"name=assists, type=INT32"`
+	GoldEarned                  int32  `parquet:"name=gold_earned, type=INT32"`
+	TotalMinionsKilled          int32  `parquet:"name=total_minions_killed, type=INT32"`
+	NeutralMinionsKilled        int32  `parquet:"name=neutral_minions_killed, type=INT32"`
+	TotalDamageDealtToChampions int32  `parquet:"name=total_damage_dealt_to_champions, type=INT32"`
+	TotalDamageTaken            int32  `parquet:"name=total_damage_taken, type=INT32"`
+	DamageDealtToObjectives     int32  `parquet:"name=damage_dealt_to_objectives, type=INT32"`
+	VisionScore                 int32  `parquet:"name=vision_score, type=INT32"`
+	TimeCCingOthers             int32  `parquet:"name=time_ccing_others, type=INT32"`
+	Item0                       int32  `parquet:"name=item0, type=INT32"`
+	Item1                       int32  `parquet:"name=item1, type=INT32"`
+	Item2                       int32  `parquet:"name=item2, type=INT32"`
+	Item3                       int32  `parquet:"name=item3, type=INT32"`
+	Item4                       int32  `parquet:"name=item4, type=INT32"`
+	Item5                       int32  `parquet:"name=item5, type=INT32"`
+	Item6                       int32  `parquet:"name=item6, type=INT32"`
+	Summoner1ID                 int32  `parquet:"name=summoner1_id, type=INT32"`
+	Summoner2ID                 int32  `parquet:"name=summoner2_id, type=INT32"`
+	// Objectives of the participant's team
+	TeamChampionKills   int32 `parquet:"name=team_champion_kills, type=INT32"`
+	TeamTowerKills      int32 `parquet:"name=team_tower_kills, type=INT32"`
+	TeamInhibitorKills  int32 `parquet:"name=team_inhibitor_kills, type=INT32"`
+	TeamDragonKills     int32 `parquet:"name=team_dragon_kills, type=INT32"`
+	TeamBaronKills      int32 `parquet:"name=team_baron_kills, type=INT32"`
+	TeamRiftHeraldKills int32 `parquet:"name=team_rift_herald_kills, type=INT32"`
+	TeamHordeKills      int32 `parquet:"name=team_horde_kills, type=INT32"`
+	TeamAtakhanKills    int32 `parquet:"name=team_atakhan_kills, type=INT32"`
+	TeamFirstBlood      bool  `parquet:"name=team_first_blood, type=BOOLEAN"`
+	TeamFirstTower      bool  `parquet:"name=team_first_tower, type=BOOLEAN"`
+	TeamFirstDragon     bool  `parquet:"name=team_first_dragon, type=BOOLEAN"`
+	TeamFirstBaron      bool  `parquet:"name=team_first_baron, type=BOOLEAN"`
+	// Runes
+	PrimaryStyle int32 `parquet:"name=perk_primary_style, type=INT32"`
+	Keystone     int32 `parquet:"name=perk_keystone, type=INT32"`
+	Primary1     int32 `parquet:"name=perk_primary1, type=INT32"`
+	Primary2     int32 `parquet:"name=perk_primary2, type=INT32"`
+	Primary3     int32 `parquet:"name=perk_primary3, type=INT32"`
+	SubStyle     int32 `parquet:"name=perk_sub_style, type=INT32"`
+	Sub1         int32 `parquet:"name=perk_sub1, type=INT32"`
+	Sub2         int32 `parquet:"name=perk_sub2, type=INT32"`
+	StatOffense  int32 `parquet:"name=perk_stat_offense, type=INT32"`
+	StatFlex     int32 `parquet:"name=perk_stat_flex, type=INT32"`
+	StatDefense  int32 `parquet:"name=perk_stat_defense, type=INT32"`
+}
+
+// ParticipantRows flattens a match into a row per participant
+func ParticipantRows(m *types.Match) []ParticipantRow {
+	teams := map[int]*types.Team{}
+	for i := range m.Info.Teams {
+		teams[m.Info.Teams[i].Teamid] = &m.Info.Teams[i]
+	}
+	rows := make([]ParticipantRow, 0, len(m.Info.Participants))
+	for _, p := range m.Info.Participants {
+		row := ParticipantRow{
+			MatchID:                     m.MetaData.MatchID,
+			PlatformID:                  m.Info.PlatformID,
+			GameVersion:                 m.Info.GameVersion,
+			Patch:                       m.Patch(),
+			QueueID:                     int32(m.Info.QueueID),
+			GameCreation:                m.Info.GameCreation,
+			GameDuration:                m.Info.GameDuration,
+			Puuid:                       p.Puuid,
+			ParticipantID:               int32(p.Participantid),
+			TeamID:                      int32(p.Teamid),
+			ChampionID:                  int32(p.Championid),
+			ChampionName:                p.Championname,
+			ChampLevel:                  int32(p.Champlevel),
+			TeamPosition:                p.Teamposition,
+			Win:                         p.Win,
+			Kills:                       int32(p.Kills),
+			Deaths:                      int32(p.Deaths),
+			Assists:                     int32(p.Assists),
+			GoldEarned:                  int32(p.Goldearned),
+			TotalMinionsKilled:          int32(p.Totalminionskilled),
+			NeutralMinionsKilled:        int32(p.Neutralminionskilled),
+			TotalDamageDealtToChampions: int32(p.Totaldamagedealttochampions),
+			TotalDamageTaken:            int32(p.Totaldamagetaken),
+			DamageDealtToObjectives:     int32(p.Damagedealttoobjectives),
+			VisionScore:                 int32(p.Visionscore),
+			TimeCCingOthers:             int32(p.Timeccingothers),
+			Item0:                       int32(p.Item0),
+			Item1:                       int32(p.Item1),
+			Item2:                       int32(p.Item2),
+			Item3:                       int32(p.Item3),
+			Item4:                       int32(p.Item4),
+			Item5:                       int32(p.Item5),
+			Item6:                       int32(p.Item6),
+			Summoner1ID:                 int32(p.Summoner1id),
+			Summoner2ID:                 int32(p.Summoner2id),
+			StatOffense:                 int32(p.Perks.StatPerks.Offense),
+			StatFlex:                    int32(p.Perks.StatPerks.Flex),
+			StatDefense:                 int32(p.Perks.StatPerks.Defense),
+		}
+		if t, ok := teams[p.Teamid]; ok {
+			o := &t.Objectives
+			row.TeamChampionKills = int32(o.Champion.Kills)
+			row.TeamTowerKills = int32(o.Tower.Kills)
+			row.TeamInhibitorKills = int32(o.Inhibitor.Kills)
+			row.TeamDragonKills = int32(o.Dragon.Kills)
+			row.TeamBaronKills = int32(o.Baron.Kills)
+			row.TeamRiftHeraldKills = int32(o.Riftherald.Kills)
+			row.TeamHordeKills = int32(o.Horde.Kills)
+			row.TeamAtakhanKills = int32(o.Atakhan.Kills)
+			row.TeamFirstBlood = o.Champion.First
+			row.TeamFirstTower = o.Tower.First
+			row.TeamFirstDragon = o.Dragon.First
+			row.TeamFirstBaron = o.Baron.First
+		}
+		for _, s := range p.Perks.Styles {
+			perks := make([]int32, 4)
+			for i, sel := range s.Selections {
+				if i < len(perks) {
+					perks[i] = int32(sel.Perk)
+				}
+			}
+			switch s.Description {
+			case "primaryStyle":
+				row.PrimaryStyle = int32(s.Style)
+				row.Keystone, row.Primary1, row.Primary2, row.Primary3 = perks[0], perks[1], perks[2], perks[3]
+			case "subStyle":
+				row.SubStyle = int32(s.Style)
+				row.Sub1, row.Sub2 = perks[0], perks[1]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ParquetManager writes a ParticipantRow per participant of every match into Parquet files partitioned by patch, e.g.
+// <dir>/patch=14.2/participants-20240131T120000-0001.parquet. Like the files of a FileManager, a file is hidden until it is complete,
+// which is the case once it holds maxRows rows, maxAge has passed since it has been created or the ParquetManager is closed.
+// The rows of a file that is not complete are lost if the crawler does not exit cleanly. Players are not written
+type ParquetManager struct {
+	dir     string
+	maxRows int64
+	maxAge  time.Duration
+	mu      sync.Mutex
+	files   map[string]*parquetFile
+	seq     int
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+type ParquetOption func(*ParquetManager) error
+
+// WithMaxRows finalizes a file once it holds maxRows rows
+func WithMaxRows(maxRows int64) func(*ParquetManager) error {
+	return func(pm *ParquetManager) error {
+		if maxRows < 1 {
+			return fmt.Errorf("Maximum Rows need to be positive (%v)", maxRows)
+		}
+		pm.maxRows = maxRows
+		return nil
+	}
+}
+
+// WithMaxAge finalizes a file once maxAge has passed since it has been created
+func WithMaxAge(maxAge time.Duration) func(*ParquetManager) error {
+	return func(pm *ParquetManager) error {
+		if maxAge <= 0 {
+			return fmt.Errorf("Maximum Age of files needs to be positive (%v)", maxAge)
+		}
+		pm.maxAge = maxAge
+		return nil
+	}
+}
+
+// NewParquetManager returns a ParquetManager writing below dir
+func NewParquetManager(dir string, opts ...ParquetOption) (*ParquetManager, error) {
+	pm := &ParquetManager{
+		dir:     dir,
+		maxRows: DefaultParquetMaxRows,
+		maxAge:  DefaultParquetMaxAge,
+		files:   make(map[string]*parquetFile),
+		quit:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if err := opt(pm); err != nil {
+			return pm, err
+		}
+	}
+	return pm, nil
+}
+
+// Init creates the directory and starts rotating files by age
+func (pm *ParquetManager) Init() error {
+	if err := os.MkdirAll(pm.dir, 0755); err != nil {
+		return err
+	}
+	pm.done = make(chan struct{})
+	go pm.run()
+	return nil
+}
+
+// Close finalizes all open files
+func (pm *ParquetManager) Close() error {
+	if pm.done != nil {
+		close(pm.quit)
+		<-pm.done
+		pm.done = nil
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	var err error
+	for patch, f := range pm.files {
+		if ferr := f.finalize(); ferr != nil && err == nil {
+			err = ferr
+		}
+		delete(pm.files, patch)
+	}
+	return err
+}
+
+// InsertMatch appends the participant rows of a match to the file of its patch
+func (pm *ParquetManager) InsertMatch(match types.Match) error {
+	rows := ParticipantRows(&match)
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	patch := match.Patch()
+	f, ok := pm.files[patch]
+	if !ok {
+		var err error
+		if f, err = pm.create(patch); err != nil {
+			return err
+		}
+		pm.files[patch] = f
+	}
+	for i := range rows {
+		if err := f.pw.Write(rows[i]); err != nil {
+			return err
+		}
+	}
+	f.rows += int64(len(rows))
+	if f.rows >= pm.maxRows {
+		delete(pm.files, patch)
+		return f.finalize()
+	}
+	return nil
+}
+
+// InsertPlayer does nothing, players are not part of the participant rows
+func (pm *ParquetManager) InsertPlayer(player types.Summoner) error {
+	return nil
+}
+
+// run finalizes the files that have reached their maximum age until the ParquetManager is closed
+func (pm *ParquetManager) run() {
+	defer close(pm.done)
+	interval := pm.maxAge / 10
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pm.quit:
+			return
+		case <-ticker.C:
+			pm.mu.Lock()
+			for patch, f := range pm.files {
+				if time.Since(f.created) < pm.maxAge {
+					continue
+				}
+				delete(pm.files, patch)
+				if err := f.finalize(); err != nil {
+					log.Errorf("Could not finalize %v: %v", f.path, err)
+				}
+			}
+			pm.mu.Unlock()
+		}
+	}
+}
+
+func (pm *ParquetManager) create(patch string) (*parquetFile, error) {
+	dir := filepath.Join(pm.dir, PARTITION_PATCH+"="+patch)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	pm.seq++
+	name := fmt.Sprintf("participants-%v-%04d.parquet", time.Now().UTC().Format("20060102T150405"), pm.seq)
+	f := &parquetFile{
+		path:    filepath.Join(dir, name),
+		tmpPath: filepath.Join(dir, "."+name+".tmp"),
+		created: time.Now(),
+	}
+	file, err := os.OpenFile(f.tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+	f.pw, err = writer.NewParquetWriterFromWriter(file, new(ParticipantRow), 1)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return f, nil
+}
+
+// parquetFile is a Parquet file that is being written to
+type parquetFile struct {
+	path    string
+	tmpPath string
+	created time.Time
+	file    *os.File
+	pw      *writer.ParquetWriter
+	rows    int64
+}
+
+// finalize writes the footer and makes the file visible under its final name
+func (f *parquetFile) finalize() error {
+	if err := f.pw.WriteStop(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.tmpPath, f.path); err != nil {
+		return err
+	}
+	log.Infof("Finalized %v (%d rows)", f.path, f.rows)
+	return nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"time"
+)
+
+// ErrNotFound is returned by a MatchReader for a match that has not been stored
+var ErrNotFound = errors.New("Match not found")
+
+// ErrUnsupported is returned by DBManagers forwarding to others, e.g. the CompositeManager, if none of them supports an operation
+var ErrUnsupported = errors.New("Not supported by the storage")
+
+// MatchQuery selects stored matches. Zero fields do not restrict the selection
+type MatchQuery struct {
+	// Puuid selects the matches a player participated in
+	Puuid   string
+	QueueID int
+	// Patch selects the matches of a patch given as major.minor, e.g. 14.2
+	Patch string
+	// Since and Until select the matches created at or after Since and before Until
+	Since time.Time
+	Until time.Time
+}
+
+// Matches reports whether a match is selected by the query
+func (q MatchQuery) Matches(m *types.Match) bool {
+	created := time.Unix(0, m.Info.GameCreation*int64(time.Millisecond))
+	if q.Puuid != "" {
+		found := false
+		for _, puuid := range m.MetaData.Participants {
+			found = found || puuid == q.Puuid
+		}
+		if !found {
+			return false
+		}
+	}
+	return (q.QueueID == 0 || m.Info.QueueID == q.QueueID) &&
+		(q.Patch == "" || m.Patch() == q.Patch) &&
+		(q.Since.IsZero() || !created.Before(q.Since)) &&
+		(q.Until.IsZero() || created.Before(q.Until))
+}
+
+// MatchReader is implemented by DBManagers that are able to look up and query their matches
+type MatchReader interface {
+	MatchIterator
+	// GetMatch returns the stored match with the given id or ErrNotFound
+	GetMatch(matchID string) (types.Match, error)
+	MatchExists(matchID string) (bool, error)
+	// FindMatches calls fn for every stored match selected by query, ordered by creation, until fn returns an error
+	FindMatches(query MatchQuery, fn func(types.Match) error) error
+	CountMatches(query MatchQuery) (int64, error)
+}
+
+// FindMatches calls fn for every match of dbm selected by query until fn returns an error.
+// Storages that are no MatchReader but a MatchIterator, e.g. a FileManager, are scanned completely
+func FindMatches(dbm DBManager, query MatchQuery, fn func(types.Match) error) error {
+	if reader, ok := dbm.(MatchReader); ok {
+		return reader.FindMatches(query, fn)
+	}
+	if it, ok := dbm.(MatchIterator); ok {
+		return it.IterateMatches(func(match types.Match) error {
+			if !query.Matches(&match) {
+				return nil
+			}
+			return fn(match)
+		})
+	}
+	return fmt.Errorf("Storage is not able to read matches back (%w)", ErrUnsupported)
+}
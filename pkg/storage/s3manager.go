@@ -0,0 +1,417 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Compressions of the objects of an S3Manager, besides GZIP
+const NO_COMPRESSION = "none"
+
+const (
+	DefaultS3Region       = "us-east-1"
+	DefaultS3ManifestSize = 1000
+)
+
+// Manifest lists the objects of a batch written by an S3Manager
+type Manifest struct {
+	CreatedAt int64    `json:"createdAt"`
+	Count     int      `json:"count"`
+	Keys      []string `json:"keys"`
+}
+
+// S3Manager writes every match and player as an object to an S3-compatible object storage, e.g. MinIO, with keys like
+// <prefix>/matches/platform=EUW1/patch=12.12/date=2022-06-30/EUW1_5954384523.json.gz and <prefix>/players/date=2022-06-30/<puuid>.json.gz.
+// After every manifestSize objects, and when closed, it writes a manifest listing the keys of the batch to <prefix>/manifests/.
+// Raw api responses are kept below <prefix>/raw/<kind>/, so that it is an Archive as well.
+// Requests are signed with AWS Signature Version 4 and address the bucket path-style (<endpoint>/<bucket>/<key>)
+type S3Manager struct {
+	endpoint     string
+	bucket       string
+	prefix       string
+	region       string
+	accessKey    string
+	secretKey    string
+	compression  string
+	manifestSize int
+	client       *http.Client
+	// CrawlerVersion is stamped onto every written match and player
+	CrawlerVersion string
+	mu             sync.Mutex
+	batch          []string
+	seq            int
+}
+
+type S3Option func(*S3Manager) error
+
+// WithS3Credentials signs the requests with the given access key
+func WithS3Credentials(accessKey string, secretKey string) func(*S3Manager) error {
+	return func(sm *S3Manager) error {
+		sm.accessKey, sm.secretKey = accessKey, secretKey
+		return nil
+	}
+}
+
+// WithS3Region sets the region requests are signed for
+func WithS3Region(region string) func(*S3Manager) error {
+	return func(sm *S3Manager) error {
+		sm.region = region
+		return nil
+	}
+}
+
+// WithObjectCompression writes the objects compressed with GZIP (default) or uncompressed (NO_COMPRESSION)
+func WithObjectCompression(compression string) func(*S3Manager) error {
+	return func(sm *S3Manager) error {
+		if compression != GZIP && compression != NO_COMPRESSION {
+			return fmt.Errorf("Unknown compression %v (%v or %v)", compression, GZIP, NO_COMPRESSION)
+		}
+		sm.compression = compression
+		return nil
+	}
+}
+
+// WithManifestSize sets the number of objects listed by a manifest
+func WithManifestSize(size int) func(*S3Manager) error {
+	return func(sm *S3Manager) error {
+		if size < 1 {
+			return fmt.Errorf("Manifest Size needs to be positive (%v)", size)
+		}
+		sm.manifestSize = size
+		return nil
+	}
+}
+
+// NewS3Manager returns an S3Manager writing to bucket below prefix at endpoint, e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+func NewS3Manager(endpoint string, bucket string, prefix string, opts ...S3Option) (*S3Manager, error) {
+	sm := &S3Manager{
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		bucket:       bucket,
+		prefix:       strings.Trim(prefix, "/"),
+		region:       DefaultS3Region,
+		compression:  GZIP,
+		manifestSize: DefaultS3ManifestSize,
+		client:       &http.Client{Timeout: CONTEXT_TIMOUT},
+	}
+	if bucket == "" {
+		return sm, fmt.Errorf("S3 bucket must not be empty")
+	}
+	for _, opt := range opts {
+		if err := opt(sm); err != nil {
+			return sm, err
+		}
+	}
+	return sm, nil
+}
+
+// Init checks that the bucket exists and is accessible with the credentials
+func (sm *S3Manager) Init() error {
+	resp, err := sm.do(http.MethodHead, "", nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("Could not reach bucket %v at %v: %v", sm.bucket, sm.endpoint, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Could not access bucket %v at %v (%v)", sm.bucket, sm.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// Close writes the manifest of the pending batch
+func (sm *S3Manager) Close() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.writeManifest()
+}
+
+// InsertMatch writes a match to the partition of its platform, patch and creation date
+func (sm *S3Manager) InsertMatch(match types.Match) error {
+	match.SchemaVersion, match.CrawlerVersion = types.SchemaVersion, sm.CrawlerVersion
+	date := time.Unix(0, match.Info.GameCreation*int64(time.Millisecond)).UTC().Format("2006-01-02")
+	key := fmt.Sprintf("%v/platform=%v/patch=%v/date=%v/%v", MATCH_COLLECTION, match.Info.PlatformID, match.Patch(), date, match.MetaData.MatchID)
+	return sm.putDocument(key, match)
+}
+
+// InsertPlayer writes a player to the partition of the date it has been crawled at
+func (sm *S3Manager) InsertPlayer(player types.Summoner) error {
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, sm.CrawlerVersion
+	key := fmt.Sprintf("%v/date=%v/%v", PLAYER_COLLECTION, time.Now().UTC().Format("2006-01-02"), player.Puuid)
+	return sm.putDocument(key, player)
+}
+
+func (sm *S3Manager) putDocument(key string, doc interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	key = sm.key(key + ".json")
+	contentType, contentEncoding := "application/json", ""
+	if sm.compression == GZIP {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(data)
+		if err := w.Close(); err != nil {
+			return err
+		}
+		data, key, contentEncoding = buf.Bytes(), key+".gz", "gzip"
+	}
+	if err := sm.Put(key, data, contentType, contentEncoding); err != nil {
+		return err
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.batch = append(sm.batch, key)
+	if len(sm.batch) >= sm.manifestSize {
+		return sm.writeManifest()
+	}
+	return nil
+}
+
+// rawExtensions are the file extensions of the raw objects by their compression
+var rawExtensions = map[string]string{GZIP: ".json.gz", ZSTD: ".json.zst"}
+
+// InsertRaw writes a raw api response as it is compressed to <prefix>/raw/<kind>/<id>.json.gz (or .json.zst).
+// Raw objects are not listed by the manifests, they are read by IterateRaw
+func (sm *S3Manager) InsertRaw(doc RawDocument) error {
+	ext, ok := rawExtensions[doc.Compression]
+	if !ok {
+		return fmt.Errorf("Unknown compression %v", doc.Compression)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Encoding", doc.Compression)
+	header.Set(s3FetchedAtHeader, strconv.FormatInt(doc.FetchedAt, 10))
+	return sm.put(sm.key(fmt.Sprintf("raw/%v/%v%v", doc.Kind, doc.ID, ext)), doc.Data, header)
+}
+
+// IterateRaw calls fn for every raw object of the given kind, listing them page by page
+func (sm *S3Manager) IterateRaw(kind string, fn func(RawDocument) error) error {
+	prefix := sm.key("raw/" + kind + "/")
+	query := map[string]string{"list-type": "2", "prefix": prefix}
+	for {
+		list := listBucketResult{}
+		if err := sm.get("", query, func(resp *http.Response) error { return xml.NewDecoder(resp.Body).Decode(&list) }); err != nil {
+			return err
+		}
+		for _, object := range list.Contents {
+			doc := RawDocument{Kind: kind}
+			for compression, ext := range rawExtensions {
+				if strings.HasSuffix(object.Key, ext) {
+					doc.ID, doc.Compression = strings.TrimSuffix(strings.TrimPrefix(object.Key, prefix), ext), compression
+				}
+			}
+			if doc.Compression == "" {
+				continue
+			}
+			err := sm.get(object.Key, nil, func(resp *http.Response) (err error) {
+				doc.FetchedAt, _ = strconv.ParseInt(resp.Header.Get(s3FetchedAtHeader), 10, 64)
+				doc.Data, err = ioutil.ReadAll(resp.Body)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+		if !list.IsTruncated {
+			return nil
+		}
+		query["continuation-token"] = list.NextContinuationToken
+	}
+}
+
+// s3FetchedAtHeader keeps the FetchedAt of a raw document as user-defined metadata of its object
+const s3FetchedAtHeader = "X-Amz-Meta-Fetched-At"
+
+// listBucketResult is the response of ListObjectsV2
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// get reads an object, or lists the bucket if key is empty, by handle
+func (sm *S3Manager) get(key string, query map[string]string, handle func(*http.Response) error) error {
+	// The objects are read as they are stored, without the transport decompressing them
+	header := http.Header{}
+	header.Set("Accept-Encoding", "identity")
+	resp, err := sm.do(http.MethodGet, key, query, nil, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Could not get %v (%v): %v", sm.bucket+"/"+key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return handle(resp)
+}
+
+// writeManifest writes the manifest of the current batch. The caller needs to hold the lock
+func (sm *S3Manager) writeManifest() error {
+	if len(sm.batch) == 0 {
+		return nil
+	}
+	now := time.Now()
+	manifest := Manifest{CreatedAt: now.UnixNano() / int64(time.Millisecond), Count: len(sm.batch), Keys: sm.batch}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	sm.seq++
+	key := sm.key(fmt.Sprintf("manifests/%v-%04d.json", now.UTC().Format("20060102T150405"), sm.seq))
+	if err := sm.Put(key, data, "application/json", ""); err != nil {
+		return err
+	}
+	log.Infof("Wrote manifest %v listing %d objects", key, len(sm.batch))
+	sm.batch = nil
+	return nil
+}
+
+func (sm *S3Manager) key(key string) string {
+	if sm.prefix == "" {
+		return key
+	}
+	return sm.prefix + "/" + key
+}
+
+// Put writes an object
+func (sm *S3Manager) Put(key string, data []byte, contentType string, contentEncoding string) error {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		header.Set("Content-Encoding", contentEncoding)
+	}
+	return sm.put(key, data, header)
+}
+
+func (sm *S3Manager) put(key string, data []byte, header http.Header) error {
+	resp, err := sm.do(http.MethodPut, key, nil, data, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Could not put %v (%v): %v", key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// do sends a signed request for an object, or for the bucket itself if key is empty
+func (sm *S3Manager) do(method string, key string, query map[string]string, data []byte, header http.Header) (*http.Response, error) {
+	u, err := url.Parse(sm.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/" + sm.bucket
+	u.RawPath = "/" + uriEncode(sm.bucket, false)
+	if key != "" {
+		u.Path += "/" + key
+		u.RawPath += "/" + uriEncode(key, false)
+	}
+	// The canonical query of Signature Version 4 is sorted by name and encoded like the path
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params := make([]string, len(names))
+	for i, name := range names {
+		params[i] = uriEncode(name, true) + "=" + uriEncode(query[name], true)
+	}
+	u.RawQuery = strings.Join(params, "&")
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	sm.sign(req, data, time.Now())
+	return sm.client.Do(req)
+}
+
+// sign adds the headers of AWS Signature Version 4 to a request. Without credentials the request stays anonymous
+func (sm *S3Manager) sign(req *http.Request, payload []byte, now time.Time) {
+	payloadHash := sha256Hex(payload)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if sm.accessKey == "" {
+		return
+	}
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	scope := fmt.Sprintf("%v/%v/s3/aws4_request", amzDate[:8], sm.region)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	key := []byte("AWS4" + sm.secretKey)
+	for _, part := range []string{amzDate[:8], sm.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		sm.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode encodes every byte but the unreserved characters as required by Signature Version 4, keeping slashes unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite writes to an embedded SQLite database file. It requires no cgo
+type SQLite struct{}
+
+// sqlitePragmas are applied to every connection: the write-ahead log lets the database be read while the crawler is writing,
+// immediate transactions and the busy timeout make concurrent writers wait for each other instead of failing
+const sqlitePragmas = "_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(10000)&_pragma=foreign_keys(1)&_txlock=immediate"
+
+// NewSQLiteManager returns a SQLManager writing to the SQLite database file at path, which is created if it does not exist
+func NewSQLiteManager(path string, opts ...SQLOption) (*SQLManager, error) {
+	dsn := "file:" + path + "?" + sqlitePragmas
+	if strings.Contains(path, "?") {
+		dsn = "file:" + path + "&" + sqlitePragmas
+	}
+	return NewSQLManager(SQLite{}, dsn, opts...)
+}
+
+func (SQLite) DriverName() string {
+	return "sqlite"
+}
+
+func (SQLite) Placeholder(i int) string {
+	return "?"
+}
+
+func (SQLite) JSONType() string {
+	return "TEXT"
+}
+
+func (SQLite) Setup(db *sql.DB) error {
+	var mode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		return err
+	}
+	if !strings.EqualFold(mode, "wal") {
+		return fmt.Errorf("Could not enable the write-ahead log of SQLite (journal mode %v)", mode)
+	}
+	return nil
+}
+
+// CopyRows inserts the rows one by one with a prepared statement, which is fast within a single transaction
+func (SQLite) CopyRows(tx *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	params := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", table, strings.Join(columns, ", "), params))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,712 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	types "go-league-crawler/pkg/types/lol"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const DefaultSQLFlushInterval = time.Second
+
+// Dialect encapsulates what differs between the relational databases a SQLManager is able to write to
+type Dialect interface {
+	// DriverName is the name the database/sql driver of the database is registered with
+	DriverName() string
+	// Placeholder returns the bind parameter of the i-th (starting at 1) argument of a statement
+	Placeholder(i int) string
+	// JSONType is the column type complete documents are stored as
+	JSONType() string
+	// Setup prepares a freshly opened database before the schema is migrated
+	Setup(db *sql.DB) error
+	// CopyRows inserts rows into a table in bulk
+	CopyRows(tx *sql.Tx, table string, columns []string, rows [][]interface{}) error
+}
+
+// SQLManager stores matches and players in a normalized relational schema: the tables participants, teams,
+// bans and perks reference the matches table by match_id, players and player_names are keyed by puuid.
+// Each row of matches also keeps the complete match (column doc), so that no field of the api is lost.
+// Matches and players are written synchronously, or collected and written in a single transaction per batch with WithSQLBatching
+type SQLManager struct {
+	Conn    *sql.DB
+	dialect Dialect
+	dsn     string
+	// CrawlerVersion is stamped onto every stored match and player
+	CrawlerVersion string
+	// Batching
+	batchSize     int
+	flushInterval time.Duration
+	mu            sync.Mutex
+	matches       []types.Match
+	players       []types.Summoner
+	quit          chan struct{}
+	done          chan struct{}
+	// OnWriteError is called with the ids of the matches and players of every batch that failed to write. Synchronous writes
+	// return their error instead
+	OnWriteError func(matchIDs []string, puuids []string, err error)
+	// OnWrite is called with the ids of the matches and players of every batch that has been committed, if it is not nil
+	OnWrite func(matchIDs []string, puuids []string)
+}
+
+type SQLOption func(*SQLManager) error
+
+// WithSQLBatching writes matches and players in transactions of batchSize documents, at the latest after flushInterval has passed.
+// A batch size of 1 writes every document synchronously
+func WithSQLBatching(batchSize int, flushInterval time.Duration) func(*SQLManager) error {
+	return func(sm *SQLManager) error {
+		if batchSize < 1 || flushInterval <= 0 {
+			return fmt.Errorf("Batch Size and Flush Interval need to be positive (%v, %v)", batchSize, flushInterval)
+		}
+		sm.batchSize = batchSize
+		sm.flushInterval = flushInterval
+		return nil
+	}
+}
+
+// NewSQLManager returns a SQLManager writing to the database given by dsn. The database is opened by Init
+func NewSQLManager(dialect Dialect, dsn string, opts ...SQLOption) (*SQLManager, error) {
+	sm := &SQLManager{
+		dialect:       dialect,
+		dsn:           dsn,
+		batchSize:     1,
+		flushInterval: DefaultSQLFlushInterval,
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+		OnWriteError: func(matchIDs []string, puuids []string, err error) {
+			log.Errorf("Could not write %d matches and %d players: %v", len(matchIDs), len(puuids), err)
+		},
+	}
+	for _, opt := range opts {
+		if err := opt(sm); err != nil {
+			return sm, err
+		}
+	}
+	return sm, nil
+}
+
+// Init opens the database, migrates its schema and starts flushing batches in the background
+func (sm *SQLManager) Init() error {
+	conn, err := sql.Open(sm.dialect.DriverName(), sm.dsn)
+	if err != nil {
+		return err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return fmt.Errorf("Could not connect to %v: %v", sm.dialect.DriverName(), err)
+	}
+	if err := sm.dialect.Setup(conn); err != nil {
+		conn.Close()
+		return err
+	}
+	sm.Conn = conn
+	if err := sm.migrate(); err != nil {
+		// Close must not wait for a run that has never been started
+		conn.Close()
+		sm.Conn = nil
+		return err
+	}
+	go sm.run()
+	return nil
+}
+
+// Close writes the pending batch and closes the database
+func (sm *SQLManager) Close() error {
+	if sm.Conn == nil {
+		return nil
+	}
+	close(sm.quit)
+	<-sm.done
+	sm.mu.Lock()
+	err := sm.flushBatch()
+	sm.mu.Unlock()
+	if cerr := sm.Conn.Close(); err == nil {
+		err = cerr
+	}
+	sm.Conn = nil
+	return err
+}
+
+// migrate applies the schema migrations the database has not seen yet, each in its own transaction
+func (sm *SQLManager) migrate() error {
+	_, err := sm.Conn.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, description TEXT NOT NULL, applied_at BIGINT NOT NULL)")
+	if err != nil {
+		return err
+	}
+	var current int
+	if err := sm.Conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+	for i, m := range sqlMigrations(sm.dialect) {
+		version := i + 1
+		if version <= current {
+			continue
+		}
+		tx, err := sm.Conn.Begin()
+		if err != nil {
+			return err
+		}
+		for _, stmt := range m.Statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("Schema migration %d (%v) failed: %v", version, m.Description, err)
+			}
+		}
+		_, err = tx.Exec(sm.insertStatement("schema_migrations", []string{"version", "description", "applied_at"}, ""),
+			version, m.Description, time.Now().UnixNano()/int64(time.Millisecond))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Infof("Applied schema migration %d: %v", version, m.Description)
+	}
+	return nil
+}
+
+// InsertMatch stores a match unless it has already been stored
+func (sm *SQLManager) InsertMatch(match types.Match) error {
+	match.SchemaVersion, match.CrawlerVersion = types.SchemaVersion, sm.CrawlerVersion
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.matches = append(sm.matches, match)
+	return sm.write()
+}
+
+// InsertPlayer stores a player or updates the stored player with the same puuid
+func (sm *SQLManager) InsertPlayer(player types.Summoner) error {
+	player.SchemaVersion, player.CrawlerVersion = types.SchemaVersion, sm.CrawlerVersion
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.players = append(sm.players, player)
+	return sm.write()
+}
+
+// write flushes the pending batch once it is full. Without batching the error is returned to the caller,
+// the failure of a batch is passed to OnWriteError. The caller needs to hold the lock
+func (sm *SQLManager) write() error {
+	if len(sm.matches)+len(sm.players) < sm.batchSize {
+		return nil
+	}
+	if sm.batchSize == 1 {
+		return sm.flush()
+	}
+	sm.flushBatch()
+	return nil
+}
+
+func (sm *SQLManager) run() {
+	defer close(sm.done)
+	ticker := time.NewTicker(sm.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sm.quit:
+			return
+		case <-ticker.C:
+			sm.mu.Lock()
+			sm.flushBatch()
+			sm.mu.Unlock()
+		}
+	}
+}
+
+// flushBatch flushes the pending batch and passes the matches and players to OnWriteError if it failed, to OnWrite otherwise.
+// The caller needs to hold the lock
+func (sm *SQLManager) flushBatch() error {
+	matches, players := sm.matches, sm.players
+	err := sm.flush()
+	if err != nil {
+		sm.OnWriteError(matchIDs(matches), puuids(players), err)
+	} else if sm.OnWrite != nil && len(matches)+len(players) > 0 {
+		sm.OnWrite(matchIDs(matches), puuids(players))
+	}
+	return err
+}
+
+// flush writes the pending matches and players in a single transaction, which are dropped if it fails. The caller needs to hold the lock
+func (sm *SQLManager) flush() error {
+	matches, players := sm.matches, sm.players
+	if len(matches) == 0 && len(players) == 0 {
+		return nil
+	}
+	sm.matches, sm.players = nil, nil
+	tx, err := sm.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	if err := sm.writeMatches(tx, matches); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := sm.writePlayers(tx, players); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Debugf("Stored %d Matches and %d Players", len(matches), len(players))
+	return nil
+}
+
+// writeMatches inserts the rows of the matches that have not been stored yet
+func (sm *SQLManager) writeMatches(tx *sql.Tx, matches []types.Match) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	insert := sm.insertStatement(matchesTable.name, matchesTable.columns, "ON CONFLICT (match_id) DO NOTHING")
+	rows := map[*sqlTable][][]interface{}{}
+	for i := range matches {
+		match := &matches[i]
+		row, err := matchRow(match)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(insert, row...)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			// Already stored
+			continue
+		}
+		rows[&participantsTable] = append(rows[&participantsTable], participantRows(match)...)
+		rows[&teamsTable] = append(rows[&teamsTable], teamRows(match)...)
+		rows[&bansTable] = append(rows[&bansTable], banRows(match)...)
+		rows[&perksTable] = append(rows[&perksTable], perkRows(match)...)
+	}
+	for _, t := range []*sqlTable{&participantsTable, &teamsTable, &bansTable, &perksTable} {
+		if len(rows[t]) == 0 {
+			continue
+		}
+		if err := sm.dialect.CopyRows(tx, t.name, t.columns, rows[t]); err != nil {
+			return fmt.Errorf("Could not copy rows into %v: %v", t.name, err)
+		}
+	}
+	return nil
+}
+
+// writePlayers upserts the players and records the names they have been observed with
+func (sm *SQLManager) writePlayers(tx *sql.Tx, players []types.Summoner) error {
+	if len(players) == 0 {
+		return nil
+	}
+	updates := []string{}
+	for _, c := range playersTable.columns[1:] {
+		updates = append(updates, fmt.Sprintf("%v = excluded.%v", c, c))
+	}
+	upsert := sm.insertStatement(playersTable.name, playersTable.columns, "ON CONFLICT (puuid) DO UPDATE SET "+strings.Join(updates, ", "))
+	observe := sm.insertStatement(playerNamesTable.name, playerNamesTable.columns, "ON CONFLICT (puuid, name) DO NOTHING")
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	for i := range players {
+		if _, err := tx.Exec(upsert, playerRow(&players[i], now)...); err != nil {
+			return err
+		}
+		if players[i].Name == "" {
+			continue
+		}
+		if _, err := tx.Exec(observe, players[i].Puuid, players[i].Name, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IterateMatches calls fn for every stored match, decoded from its complete document, until fn returns an error
+func (sm *SQLManager) IterateMatches(fn func(types.Match) error) error {
+	return sm.FindMatches(MatchQuery{}, fn)
+}
+
+// GetMatch returns the stored match with the given id, decoded from its complete document. Matches of the pending batch
+// are not flushed for point lookups, which would defeat batching, thus they are not found until their batch has been written
+func (sm *SQLManager) GetMatch(matchID string) (types.Match, error) {
+	match := types.Match{}
+	var doc string
+	err := sm.Conn.QueryRow("SELECT doc FROM matches WHERE match_id = "+sm.dialect.Placeholder(1), matchID).Scan(&doc)
+	if err == sql.ErrNoRows {
+		return match, ErrNotFound
+	}
+	if err != nil {
+		return match, err
+	}
+	return match, json.Unmarshal([]byte(doc), &match)
+}
+
+// MatchExists reports whether a match is stored, not considering the pending batch like GetMatch
+func (sm *SQLManager) MatchExists(matchID string) (bool, error) {
+	var n int
+	err := sm.Conn.QueryRow("SELECT COUNT(*) FROM matches WHERE match_id = "+sm.dialect.Placeholder(1), matchID).Scan(&n)
+	return n > 0, err
+}
+
+// FindMatches calls fn for every stored match selected by query, ordered by creation, until fn returns an error
+func (sm *SQLManager) FindMatches(query MatchQuery, fn func(types.Match) error) error {
+	if err := sm.flushPending(); err != nil {
+		return err
+	}
+	where, args := sm.matchWhere(query)
+	rows, err := sm.Conn.Query("SELECT doc FROM matches"+where+" ORDER BY game_creation", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return err
+		}
+		match := types.Match{}
+		if err := json.Unmarshal([]byte(doc), &match); err != nil {
+			return err
+		}
+		if err := fn(match); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (sm *SQLManager) CountMatches(query MatchQuery) (int64, error) {
+	if err := sm.flushPending(); err != nil {
+		return 0, err
+	}
+	where, args := sm.matchWhere(query)
+	var n int64
+	err := sm.Conn.QueryRow("SELECT COUNT(*) FROM matches"+where, args...).Scan(&n)
+	return n, err
+}
+
+// flushPending writes the pending batch, so that queries see every inserted match
+func (sm *SQLManager) flushPending() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.flushBatch()
+}
+
+// matchWhere translates a MatchQuery into a WHERE clause on the matches table and its arguments
+func (sm *SQLManager) matchWhere(query MatchQuery) (string, []interface{}) {
+	conditions, args := []string{}, []interface{}{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, sm.dialect.Placeholder(len(args))))
+	}
+	if query.Puuid != "" {
+		add("match_id IN (SELECT match_id FROM participants WHERE puuid = %v)", query.Puuid)
+	}
+	if query.QueueID != 0 {
+		add("queue_id = %v", query.QueueID)
+	}
+	if query.Patch != "" {
+		add("patch = %v", query.Patch)
+	}
+	if !query.Since.IsZero() {
+		add("game_creation >= %v", query.Since.UnixNano()/int64(time.Millisecond))
+	}
+	if !query.Until.IsZero() {
+		add("game_creation < %v", query.Until.UnixNano()/int64(time.Millisecond))
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// insertStatement returns an insert of a single row into table, followed by suffix, e.g. an ON CONFLICT clause
+func (sm *SQLManager) insertStatement(table string, columns []string, suffix string) string {
+	params := make([]string, len(columns))
+	for i := range columns {
+		params[i] = sm.dialect.Placeholder(i + 1)
+	}
+	return fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v) %v", table, strings.Join(columns, ", "), strings.Join(params, ", "), suffix)
+}
+
+func matchIDs(matches []types.Match) []string {
+	ids := make([]string, len(matches))
+	for i := range matches {
+		ids[i] = matches[i].MetaData.MatchID
+	}
+	return ids
+}
+
+func puuids(players []types.Summoner) []string {
+	ids := make([]string, len(players))
+	for i := range players {
+		ids[i] = players[i].Puuid
+	}
+	return ids
+}
+
+// sqlTable describes a table of the relational schema, its columns being in the order of the rows built for it
+type sqlTable struct {
+	name    string
+	columns []string
+}
+
+var (
+	matchesTable = sqlTable{"matches", []string{
+		"match_id", "platform_id", "game_id", "queue_id", "map_id", "game_mode", "game_type", "game_version", "patch",
+		"game_creation", "game_start", "game_end", "game_duration", "ended_in_surrender", "tournament_code",
+		"schema_version", "crawler_version", "doc",
+	}}
+	participantsTable = sqlTable{"participants", []string{
+		"match_id", "participant_id", "puuid", "summoner_id", "summoner_name", "riot_id_game_name", "riot_id_tagline",
+		"team_id", "champion_id", "champion_name", "champ_level", "team_position", "individual_position", "lane", "role", "win",
+		"kills", "deaths", "assists", "double_kills", "triple_kills", "quadra_kills", "penta_kills", "first_blood_kill", "first_tower_kill",
+		"gold_earned", "gold_spent", "total_minions_killed", "neutral_minions_killed",
+		"total_damage_dealt_to_champions", "total_damage_taken", "damage_self_mitigated", "damage_dealt_to_objectives",
+		"damage_dealt_to_buildings", "total_heal", "time_ccing_others", "vision_score", "wards_placed", "wards_killed",
+		"detector_wards_placed", "turret_kills", "inhibitor_kills", "time_played",
+		"item0", "item1", "item2", "item3", "item4", "item5", "item6", "summoner1_id", "summoner2_id",
+	}}
+	teamsTable = sqlTable{"teams", []string{
+		"match_id", "team_id", "win", "first_blood", "first_tower", "first_inhibitor", "first_dragon", "first_baron",
+		"first_rift_herald", "champion_kills", "tower_kills", "inhibitor_kills", "dragon_kills", "baron_kills",
+		"rift_herald_kills", "horde_kills", "atakhan_kills",
+	}}
+	bansTable  = sqlTable{"bans", []string{"match_id", "team_id", "pick_turn", "champion_id"}}
+	perksTable = sqlTable{"perks", []string{
+		"match_id", "participant_id", "style_description", "style", "slot", "perk", "var1", "var2", "var3",
+	}}
+	playersTable = sqlTable{"players", []string{
+		"puuid", "summoner_id", "account_id", "name", "profile_icon_id", "revision_date", "summoner_level",
+		"schema_version", "crawler_version", "updated_at",
+	}}
+	playerNamesTable = sqlTable{"player_names", []string{"puuid", "name", "observed_at"}}
+)
+
+// sqlMigration is a step of the relational schema, its version being its position in sqlMigrations (starting at 1)
+type sqlMigration struct {
+	Description string
+	Statements  []string
+}
+
+// sqlMigrations returns the steps of the relational schema. Steps must never be changed once released, only appended
+func sqlMigrations(d Dialect) []sqlMigration {
+	return []sqlMigration{
+		{
+			Description: "create matches, participants, teams, bans, perks, players and player_names",
+			Statements: []string{
+				`CREATE TABLE matches (
+					match_id TEXT PRIMARY KEY,
+					platform_id TEXT NOT NULL,
+					game_id BIGINT NOT NULL,
+					queue_id INTEGER NOT NULL,
+					map_id INTEGER NOT NULL,
+					game_mode TEXT NOT NULL,
+					game_type TEXT NOT NULL,
+					game_version TEXT NOT NULL,
+					patch TEXT NOT NULL,
+					game_creation BIGINT NOT NULL,
+					game_start BIGINT NOT NULL,
+					game_end BIGINT NOT NULL,
+					game_duration BIGINT NOT NULL,
+					ended_in_surrender BOOLEAN NOT NULL,
+					tournament_code TEXT NOT NULL,
+					schema_version INTEGER NOT NULL,
+					crawler_version TEXT NOT NULL,
+					doc ` + d.JSONType() + ` NOT NULL
+				)`,
+				"CREATE INDEX matches_queue_patch ON matches (queue_id, patch)",
+				"CREATE INDEX matches_game_creation ON matches (game_creation)",
+				`CREATE TABLE participants (
+					match_id TEXT NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+					participant_id INTEGER NOT NULL,
+					puuid TEXT NOT NULL,
+					summoner_id TEXT NOT NULL,
+					summoner_name TEXT NOT NULL,
+					riot_id_game_name TEXT NOT NULL,
+					riot_id_tagline TEXT NOT NULL,
+					team_id INTEGER NOT NULL,
+					champion_id INTEGER NOT NULL,
+					champion_name TEXT NOT NULL,
+					champ_level INTEGER NOT NULL,
+					team_position TEXT NOT NULL,
+					individual_position TEXT NOT NULL,
+					lane TEXT NOT NULL,
+					role TEXT NOT NULL,
+					win BOOLEAN NOT NULL,
+					kills INTEGER NOT NULL,
+					deaths INTEGER NOT NULL,
+					assists INTEGER NOT NULL,
+					double_kills INTEGER NOT NULL,
+					triple_kills INTEGER NOT NULL,
+					quadra_kills INTEGER NOT NULL,
+					penta_kills INTEGER NOT NULL,
+					first_blood_kill BOOLEAN NOT NULL,
+					first_tower_kill BOOLEAN NOT NULL,
+					gold_earned INTEGER NOT NULL,
+					gold_spent INTEGER NOT NULL,
+					total_minions_killed INTEGER NOT NULL,
+					neutral_minions_killed INTEGER NOT NULL,
+					total_damage_dealt_to_champions INTEGER NOT NULL,
+					total_damage_taken INTEGER NOT NULL,
+					damage_self_mitigated INTEGER NOT NULL,
+					damage_dealt_to_objectives INTEGER NOT NULL,
+					damage_dealt_to_buildings INTEGER NOT NULL,
+					total_heal INTEGER NOT NULL,
+					time_ccing_others INTEGER NOT NULL,
+					vision_score INTEGER NOT NULL,
+					wards_placed INTEGER NOT NULL,
+					wards_killed INTEGER NOT NULL,
+					detector_wards_placed INTEGER NOT NULL,
+					turret_kills INTEGER NOT NULL,
+					inhibitor_kills INTEGER NOT NULL,
+					time_played INTEGER NOT NULL,
+					item0 INTEGER NOT NULL,
+					item1 INTEGER NOT NULL,
+					item2 INTEGER NOT NULL,
+					item3 INTEGER NOT NULL,
+					item4 INTEGER NOT NULL,
+					item5 INTEGER NOT NULL,
+					item6 INTEGER NOT NULL,
+					summoner1_id INTEGER NOT NULL,
+					summoner2_id INTEGER NOT NULL,
+					PRIMARY KEY (match_id, participant_id)
+				)`,
+				"CREATE INDEX participants_puuid ON participants (puuid)",
+				"CREATE INDEX participants_champion ON participants (champion_id, team_position)",
+				`CREATE TABLE teams (
+					match_id TEXT NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+					team_id INTEGER NOT NULL,
+					win BOOLEAN NOT NULL,
+					first_blood BOOLEAN NOT NULL,
+					first_tower BOOLEAN NOT NULL,
+					first_inhibitor BOOLEAN NOT NULL,
+					first_dragon BOOLEAN NOT NULL,
+					first_baron BOOLEAN NOT NULL,
+					first_rift_herald BOOLEAN NOT NULL,
+					champion_kills INTEGER NOT NULL,
+					tower_kills INTEGER NOT NULL,
+					inhibitor_kills INTEGER NOT NULL,
+					dragon_kills INTEGER NOT NULL,
+					baron_kills INTEGER NOT NULL,
+					rift_herald_kills INTEGER NOT NULL,
+					horde_kills INTEGER NOT NULL,
+					atakhan_kills INTEGER NOT NULL,
+					PRIMARY KEY (match_id, team_id)
+				)`,
+				`CREATE TABLE bans (
+					match_id TEXT NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+					team_id INTEGER NOT NULL,
+					pick_turn INTEGER NOT NULL,
+					champion_id INTEGER NOT NULL,
+					PRIMARY KEY (match_id, team_id, pick_turn)
+				)`,
+				`CREATE TABLE perks (
+					match_id TEXT NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+					participant_id INTEGER NOT NULL,
+					style_description TEXT NOT NULL,
+					style INTEGER NOT NULL,
+					slot INTEGER NOT NULL,
+					perk INTEGER NOT NULL,
+					var1 INTEGER NOT NULL,
+					var2 INTEGER NOT NULL,
+					var3 INTEGER NOT NULL,
+					PRIMARY KEY (match_id, participant_id, style_description, slot)
+				)`,
+				`CREATE TABLE players (
+					puuid TEXT PRIMARY KEY,
+					summoner_id TEXT NOT NULL,
+					account_id TEXT NOT NULL,
+					name TEXT NOT NULL,
+					profile_icon_id INTEGER NOT NULL,
+					revision_date BIGINT NOT NULL,
+					summoner_level BIGINT NOT NULL,
+					schema_version INTEGER NOT NULL,
+					crawler_version TEXT NOT NULL,
+					updated_at BIGINT NOT NULL
+				)`,
+				`CREATE TABLE player_names (
+					puuid TEXT NOT NULL REFERENCES players (puuid) ON DELETE CASCADE,
+					name TEXT NOT NULL,
+					observed_at BIGINT NOT NULL,
+					PRIMARY KEY (puuid, name)
+				)`,
+			},
+		},
+	}
+}
+
+func matchRow(m *types.Match) ([]interface{}, error) {
+	doc, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	i := &m.Info
+	return []interface{}{
+		m.MetaData.MatchID, i.PlatformID, i.GameID, i.QueueID, i.MapID, i.GameMode, i.GameType, i.GameVersion, m.Patch(),
+		i.GameCreation, i.GameStartTimestamp, i.GameEndTimestamp, i.GameDuration, i.GameEndedInSurrender, i.TournamentCode,
+		m.SchemaVersion, m.CrawlerVersion, string(doc),
+	}, nil
+}
+
+func participantRows(m *types.Match) [][]interface{} {
+	rows := [][]interface{}{}
+	for _, p := range m.Info.Participants {
+		rows = append(rows, []interface{}{
+			m.MetaData.MatchID, p.Participantid, p.Puuid, p.Summonerid, p.Summonername, p.Riotidgamename, p.Riotidtagline,
+			p.Teamid, p.Championid, p.Championname, p.Champlevel, p.Teamposition, p.Individualposition, p.Lane, p.Role, p.Win,
+			p.Kills, p.Deaths, p.Assists, p.Doublekills, p.Triplekills, p.Quadrakills, p.Pentakills, p.Firstbloodkill, p.Firsttowerkill,
+			p.Goldearned, p.Goldspent, p.Totalminionskilled, p.Neutralminionskilled,
+			p.Totaldamagedealttochampions, p.Totaldamagetaken, p.Damageselfmitigated, p.Damagedealttoobjectives,
+			p.Damagedealttobuildings, p.Totalheal, p.Timeccingothers, p.Visionscore, p.Wardsplaced, p.Wardskilled,
+			p.Detectorwardsplaced, p.Turretkills, p.Inhibitorkills, p.Timeplayed,
+			p.Item0, p.Item1, p.Item2, p.Item3, p.Item4, p.Item5, p.Item6, p.Summoner1id, p.Summoner2id,
+		})
+	}
+	return rows
+}
+
+func teamRows(m *types.Match) [][]interface{} {
+	rows := [][]interface{}{}
+	for _, t := range m.Info.Teams {
+		o := &t.Objectives
+		rows = append(rows, []interface{}{
+			m.MetaData.MatchID, t.Teamid, t.Win, o.Champion.First, o.Tower.First, o.Inhibitor.First, o.Dragon.First, o.Baron.First,
+			o.Riftherald.First, o.Champion.Kills, o.Tower.Kills, o.Inhibitor.Kills, o.Dragon.Kills, o.Baron.Kills,
+			o.Riftherald.Kills, o.Horde.Kills, o.Atakhan.Kills,
+		})
+	}
+	return rows
+}
+
+func banRows(m *types.Match) [][]interface{} {
+	rows := [][]interface{}{}
+	for _, t := range m.Info.Teams {
+		for _, b := range t.Bans {
+			rows = append(rows, []interface{}{m.MetaData.MatchID, t.Teamid, b.PickTurn, b.ChampionId})
+		}
+	}
+	return rows
+}
+
+// perkRows returns a row per selected rune. The stat shards are stored with the style description "statPerks"
+// in the slots 0 (offense), 1 (flex) and 2 (defense)
+func perkRows(m *types.Match) [][]interface{} {
+	rows := [][]interface{}{}
+	for _, p := range m.Info.Participants {
+		for _, s := range p.Perks.Styles {
+			for slot, sel := range s.Selections {
+				rows = append(rows, []interface{}{m.MetaData.MatchID, p.Participantid, s.Description, s.Style, slot, sel.Perk, sel.Var1, sel.Var2, sel.Var3})
+			}
+		}
+		for slot, perk := range []int{p.Perks.StatPerks.Offense, p.Perks.StatPerks.Flex, p.Perks.StatPerks.Defense} {
+			rows = append(rows, []interface{}{m.MetaData.MatchID, p.Participantid, "statPerks", 0, slot, perk, 0, 0, 0})
+		}
+	}
+	return rows
+}
+
+func playerRow(p *types.Summoner, updatedAt int64) []interface{} {
+	return []interface{}{
+		p.Puuid, p.Id, p.AccountId, p.Name, p.ProfileIconId, p.RevisionDate, p.SummonerLevel,
+		p.SchemaVersion, p.CrawlerVersion, updatedAt,
+	}
+}
@@ -0,0 +1,9 @@
+package types
+
+// Account reflects the AccountDto object of account-v1 according to riot api documentation.
+// Together, GameName and TagLine form the Riot ID of a player, e.g. dwaynehart#EUW
+type Account struct {
+	Puuid    string `json:"puuid"`
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+}
@@ -0,0 +1,37 @@
+package types
+
+// PlayerChallenges reflects the PlayerInfoDto object of challenges-v1 according to riot api documentation.
+// Puuid and Timestamp are not part of the api, they record whose progress has been fetched at which time (unix milliseconds)
+type PlayerChallenges struct {
+	Puuid          string                     `bson:"puuid" json:"puuid"`
+	Timestamp      int64                      `bson:"timestamp" json:"timestamp"`
+	Challenges     []ChallengeInfo            `bson:"challenges" json:"challenges"`
+	Preferences    ChallengePreferences       `bson:"preferences" json:"preferences"`
+	TotalPoints    ChallengePoints            `bson:"totalPoints" json:"totalPoints"`
+	CategoryPoints map[string]ChallengePoints `bson:"categoryPoints" json:"categoryPoints"`
+	SchemaVersion  int                        `bson:"schemaVersion" json:"schemaVersion,omitempty"`
+	CrawlerVersion string                     `bson:"crawlerVersion,omitempty" json:"crawlerVersion,omitempty"`
+}
+
+type ChallengeInfo struct {
+	ChallengeID  int64   `bson:"challengeId" json:"challengeId"`
+	Percentile   float64 `bson:"percentile" json:"percentile"`
+	Level        string  `bson:"level" json:"level"`
+	Value        float64 `bson:"value" json:"value"`
+	AchievedTime int64   `bson:"achievedTime" json:"achievedTime"`
+}
+
+type ChallengePoints struct {
+	Level      string  `bson:"level" json:"level"`
+	Current    int64   `bson:"current" json:"current"`
+	Max        int64   `bson:"max" json:"max"`
+	Percentile float64 `bson:"percentile" json:"percentile"`
+}
+
+type ChallengePreferences struct {
+	BannerAccent             string  `bson:"bannerAccent" json:"bannerAccent"`
+	Title                    string  `bson:"title" json:"title"`
+	ChallengeIDs             []int64 `bson:"challengeIds" json:"challengeIds"`
+	CrestBorder              string  `bson:"crestBorder" json:"crestBorder"`
+	PrestigeCrestBorderLevel int     `bson:"prestigeCrestBorderLevel" json:"prestigeCrestBorderLevel"`
+}
@@ -0,0 +1,43 @@
+package types
+
+// ClashTournament reflects the TournamentDto object of clash-v1 according to riot api documentation
+type ClashTournament struct {
+	ID               int                    `bson:"id" json:"id"`
+	ThemeID          int                    `bson:"themeId" json:"themeId"`
+	NameKey          string                 `bson:"nameKey" json:"nameKey"`
+	NameKeySecondary string                 `bson:"nameKeySecondary" json:"nameKeySecondary"`
+	Schedule         []ClashTournamentPhase `bson:"schedule" json:"schedule"`
+}
+
+type ClashTournamentPhase struct {
+	ID               int   `bson:"id" json:"id"`
+	RegistrationTime int64 `bson:"registrationTime" json:"registrationTime"`
+	StartTime        int64 `bson:"startTime" json:"startTime"`
+	Cancelled        bool  `bson:"cancelled" json:"cancelled"`
+}
+
+// ClashTeam reflects the TeamDto object of clash-v1 according to riot api documentation.
+// Matches is not part of the api, it links the team to the crawled matches its roster played together
+type ClashTeam struct {
+	ID             string        `bson:"id" json:"id"`
+	TournamentID   int           `bson:"tournamentId" json:"tournamentId"`
+	Name           string        `bson:"name" json:"name"`
+	IconID         int           `bson:"iconId" json:"iconId"`
+	Tier           int           `bson:"tier" json:"tier"`
+	Captain        string        `bson:"captain" json:"captain"` // SummonerId of the team captain
+	Abbreviation   string        `bson:"abbreviation" json:"abbreviation"`
+	Players        []ClashPlayer `bson:"players" json:"players"`
+	Matches        []string      `bson:"matches,omitempty" json:"-"`
+	SchemaVersion  int           `bson:"schemaVersion" json:"schemaVersion,omitempty"`
+	CrawlerVersion string        `bson:"crawlerVersion,omitempty" json:"crawlerVersion,omitempty"`
+}
+
+// ClashPlayer reflects the PlayerDto object of clash-v1 according to riot api documentation.
+// Puuid is resolved by the crawler, since the api only provides the summonerId
+type ClashPlayer struct {
+	SummonerID string `bson:"summonerId" json:"summonerId"`
+	Puuid      string `bson:"puuid" json:"puuid"`
+	TeamID     string `bson:"teamId" json:"teamId"`
+	Position   string `bson:"position" json:"position"` // (Legal values: UNSELECTED, FILL, TOP, JUNGLE, MIDDLE, BOTTOM, UTILITY)
+	Role       string `bson:"role" json:"role"`         // (Legal values: CAPTAIN, MEMBER)
+}
@@ -0,0 +1,56 @@
+package types
+
+// Ranked queues of league-v4
+const (
+	QueueRankedSolo = "RANKED_SOLO_5x5"
+	QueueRankedFlex = "RANKED_FLEX_SR"
+)
+
+// LeagueEntry reflects the LeagueEntryDTO object of league-v4 according to riot api documentation
+type LeagueEntry struct {
+	LeagueID     string      `json:"leagueId"`
+	Puuid        string      `json:"puuid"`
+	SummonerID   string      `json:"summonerId"`
+	QueueType    string      `json:"queueType"`
+	Tier         string      `json:"tier"`
+	Rank         string      `json:"rank"`
+	LeaguePoints int         `json:"leaguePoints"`
+	Wins         int         `json:"wins"`
+	Losses       int         `json:"losses"`
+	HotStreak    bool        `json:"hotStreak"`
+	Veteran      bool        `json:"veteran"`
+	FreshBlood   bool        `json:"freshBlood"`
+	Inactive     bool        `json:"inactive"`
+	MiniSeries   *MiniSeries `json:"miniSeries,omitempty"`
+}
+
+type MiniSeries struct {
+	Losses   int    `json:"losses"`
+	Progress string `json:"progress"`
+	Target   int    `json:"target"`
+	Wins     int    `json:"wins"`
+}
+
+// LeagueList reflects the LeagueListDTO object of league-v4 according to riot api documentation,
+// e.g. the challenger league of a queue
+type LeagueList struct {
+	LeagueID string       `json:"leagueId"`
+	Entries  []LeagueItem `json:"entries"`
+	Tier     string       `json:"tier"`
+	Name     string       `json:"name"`
+	Queue    string       `json:"queue"`
+}
+
+type LeagueItem struct {
+	Puuid        string      `json:"puuid"`
+	SummonerID   string      `json:"summonerId"`
+	Rank         string      `json:"rank"`
+	LeaguePoints int         `json:"leaguePoints"`
+	Wins         int         `json:"wins"`
+	Losses       int         `json:"losses"`
+	FreshBlood   bool        `json:"freshBlood"`
+	Inactive     bool        `json:"inactive"`
+	Veteran      bool        `json:"veteran"`
+	HotStreak    bool        `json:"hotStreak"`
+	MiniSeries   *MiniSeries `json:"miniSeries,omitempty"`
+}
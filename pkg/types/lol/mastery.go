@@ -0,0 +1,15 @@
+package types
+
+// ChampionMastery reflects the ChampionMasteryDto object of champion-mastery-v4 according to riot api documentation.
+// LastPlayTime is given in unix milliseconds
+type ChampionMastery struct {
+	Puuid                        string `json:"puuid"`
+	ChampionID                   int64  `json:"championId"`
+	ChampionLevel                int    `json:"championLevel"`
+	ChampionPoints               int    `json:"championPoints"`
+	ChampionPointsSinceLastLevel int64  `json:"championPointsSinceLastLevel"`
+	ChampionPointsUntilNextLevel int64  `json:"championPointsUntilNextLevel"`
+	ChestGranted                 bool   `json:"chestGranted"`
+	TokensEarned                 int    `json:"tokensEarned"`
+	LastPlayTime                 int64  `json:"lastPlayTime"`
+}
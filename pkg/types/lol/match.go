@@ -1,189 +1,271 @@
 package types
 
-// Match reflects the MatchDTO object according to riot api documentation
+import "strings"
+
+// SchemaVersion is the version of the types of this package, i.e. of the documents stored based on them.
+// It needs to be incremented whenever a change to the types requires stored documents to be migrated
+const SchemaVersion = 1
+
+// Match reflects the MatchDTO object according to riot api documentation.
+// SchemaVersion and CrawlerVersion are not part of the api, they record which versions wrote a stored document
 type Match struct {
-	MetaData MetaData `bson:"metaData" json:"metadata"`
-	Info     Info     `json:"info"`
+	MetaData       MetaData `bson:"metaData" json:"metadata"`
+	Info           Info     `bson:"info" json:"info"`
+	SchemaVersion  int      `bson:"schemaVersion" json:"schemaVersion,omitempty"`
+	CrawlerVersion string   `bson:"crawlerVersion,omitempty" json:"crawlerVersion,omitempty"`
 }
 
 type MetaData struct {
-	Dataversion  string   `json:"dataversion"`
-	MatchID      string   `json:"matchId"`
-	Participants []string `json:"participants"`
+	Dataversion  string   `bson:"dataVersion" json:"dataVersion"`
+	MatchID      string   `bson:"matchId" json:"matchId"`
+	Participants []string `bson:"participants" json:"participants"` // A list of participant PUUIDs
 }
 
 type Info struct {
-	GameCreation       int64         `bson:"gameCreation" json:"gameCreation"`
-	GameDuration       int64         `bson:"gameDuration" json:"gameDuration"`
-	GameEndTimestamp   int64         `bson:"gameEndTimestamp" json:"gameEndTimestamp"`
-	GameID             int64         `bson:"gameId" json:"gameId"`
-	GameMode           string        `bson:"gameMode" json:"gameMode"`
-	GameName           string        `bson:"gameName" json:"gameName"`
-	GameStartTimestamp int64         `bson:"gameStartTimestamp" json:"gameStartTimestamp"`
-	GameType           string        `bson:"gameType" json:"gameType"`
-	GameVersion        string        `bson:"gameVersion" json:"gameVersion"`
-	MapID              int           `bson:"mapId" json:"mapId"`
-	Participants       []Participant `bson:"participants" json:"participants"`
-	PlatformID         string        `bson:"platformId" json:"platformId"`
-	QueueID            int           `bson:"queueId" json:"queueId"`
-	Teams              []Team        `bson:"teams" json:"teams"`
-	TournamentCode     string        `bson:"tournamentCode" json:"tournamentCode"`
+	EndOfGameResult      string        `bson:"endOfGameResult" json:"endOfGameResult"` // e.g. GameComplete
+	GameCreation         int64         `bson:"gameCreation" json:"gameCreation"`
+	GameDuration         int64         `bson:"gameDuration" json:"gameDuration"` // Prior to patch 11.20, this field returns the game length in milliseconds calculated from gameEndTimestamp - gameStartTimestamp. Post patch 11.20, this field returns the max timePlayed of any participant in the game in seconds
+	GameEndedInSurrender bool          `bson:"gameEndedInSurrender" json:"gameEndedInSurrender"`
+	GameEndTimestamp     int64         `bson:"gameEndTimestamp" json:"gameEndTimestamp"`
+	GameID               int64         `bson:"gameId" json:"gameId"`
+	GameMode             string        `bson:"gameMode" json:"gameMode"`
+	GameName             string        `bson:"gameName" json:"gameName"`
+	GameStartTimestamp   int64         `bson:"gameStartTimestamp" json:"gameStartTimestamp"`
+	GameType             string        `bson:"gameType" json:"gameType"`
+	GameVersion          string        `bson:"gameVersion" json:"gameVersion"`
+	MapID                int           `bson:"mapId" json:"mapId"`
+	Participants         []Participant `bson:"participants" json:"participants"`
+	PlatformID           string        `bson:"platformId" json:"platformId"`
+	QueueID              int           `bson:"queueId" json:"queueId"`
+	Teams                []Team        `bson:"teams" json:"teams"`
+	TournamentCode       string        `bson:"tournamentCode" json:"tournamentCode"`
 }
 
 type Participant struct {
-	Assists                        int    `json:"assists"`
-	Baronkills                     int    `json:"baronKills"`
-	Bountylevel                    int    `json:"bountyLevel"`
-	Champexperience                int    `json:"champExperience"`
-	Champlevel                     int    `json:"champLevel"`
-	Championid                     int    `json:"championId"` // Prior to patch 11.4, on Feb 18th, 2021, this field returned invalid championIds. We recommend determining the champion based on the championName field for matches played prior to patch 11.4.
-	Championname                   string `json:"championName"`
-	Championtransform              int    `json:"championTransform"` // This field is currently only utilized for Kayn's transformations. (Legal values: 0 - None, 1 - Slayer, 2 - Assassin)
-	Consumablespurchased           int    `json:"consumablesPurchased"`
-	Damagedealttobuildings         int    `json:"damageDealtToBuildings"`
-	Damagedealttoobjectives        int    `json:"damageDealtToObjectives"`
-	Damagedealttoturrets           int    `json:"damageDealtToTurrets"`
-	Damageselfmitigated            int    `json:"damageSelfMitigated"`
-	Deaths                         int    `json:"deaths"`
-	Detectorwardsplaced            int    `json:"detectorWardsPlaced"`
-	Doublekills                    int    `json:"doubleKills"`
-	Dragonkills                    int    `json:"dragonKills"`
-	Firstbloodassist               bool   `json:"firstBloodAssist"`
-	Firstbloodkill                 bool   `json:"firstBloodKill"`
-	Firsttowerassist               bool   `json:"firstTowerAssist"`
-	Firsttowerkill                 bool   `json:"firstTowerKill"`
-	Gameendedinearlysurrender      bool   `json:"gameEndedInEarlySurrender"`
-	Gameendedinsurrender           bool   `json:"gameEndedInSurrender"`
-	Goldearned                     int    `json:"goldEarned"`
-	Goldspent                      int    `json:"goldSpent"`
-	Individualposition             string `json:"individualPosition"` // Both individualPosition and teamPosition are computed by the game server and are different versions of the most likely position played by a player. The individualPosition is the best guess for which position the player actually played in isolation of anything else. The teamPosition is the best guess for which position the player actually played if we add the constraint that each team must have one top player, one jungle, one middle, etc. Generally the recommendation is to use the teamPosition field over the individualPosition field.
-	Inhibitorkills                 int    `json:"inhibitorKills"`
-	Inhibitortakedowns             int    `json:"inhibitorTakedowns"`
-	Inhibitorslost                 int    `json:"inhibitorsLost"`
-	Item0                          int    `json:"item0"`
-	Item1                          int    `json:"item1"`
-	Item2                          int    `json:"item2"`
-	Item3                          int    `json:"item3"`
-	Item4                          int    `json:"item4"`
-	Item5                          int    `json:"item5"`
-	Item6                          int    `json:"item6"`
-	Itemspurchased                 int    `json:"itemsPurchased"`
-	Killingsprees                  int    `json:"killingSprees"`
-	Kills                          int    `json:"kills"`
-	Lane                           string `json:"lane"`
-	Largestcriticalstrike          int    `json:"largestCriticalStrike"`
-	Largestkillingspree            int    `json:"largestKillingSpree"`
-	Largestmultikill               int    `json:"largestMultiKill"`
-	Longesttimespentliving         int    `json:"longestTimeSpentLiving"`
-	Magicdamagedealt               int    `json:"magicDamageDealt"`
-	Magicdamagedealttochampions    int    `json:"magicDamageDealtToChampions"`
-	Magicdamagetaken               int    `json:"magicDamageTaken"`
-	Neutralminionskilled           int    `json:"neutralMinionsKilled"`
-	Nexuskills                     int    `json:"nexusKills"`
-	Nexustakedowns                 int    `json:"nexusTakedowns"`
-	Nexuslost                      int    `json:"nexuslost"`
-	Objectivesstolen               int    `json:"objectivesStolen"`
-	Objectivesstolenassists        int    `json:"objectivesStolenAssists"`
-	Participantid                  int    `json:"participantId"`
-	Pentakills                     int    `json:"pentaKills"`
-	Perks                          Perk   `json:"perks"`
-	Physicaldamagedealt            int    `json:"physicalDamageDealt"`
-	Physicaldamagedealttochampions int    `json:"physicalDamageDealtToChampions"`
-	Physicaldamagetaken            int    `json:"physicalDamageTaken"`
-	Profileicon                    int    `json:"profileIcon"`
-	Puuid                          string `json:"puuid"`
-	Quadrakills                    int    `json:"quadraKills"`
-	Riotidname                     string `json:"riotIdName"`
-	Riotidtagline                  string `json:"riotIdTagline"`
-	Role                           string `json:"role"`
-	Sightwardsboughtingame         int    `json:"sightWardsBoughtInGame"`
-	Spell1casts                    int    `json:"spell1Casts"`
-	Spell2casts                    int    `json:"spell2Casts"`
-	Spell3casts                    int    `json:"spell3Casts"`
-	Spell4casts                    int    `json:"spell4Casts"`
-	Summoner1casts                 int    `json:"summoner1Casts"`
-	Summoner1id                    int    `json:"summoner1Id"`
-	Summoner2casts                 int    `json:"summoner2Casts"`
-	Summoner2id                    int    `json:"summoner2Id"`
-	Summonerid                     string `json:"summonerId"`
-	Summonerlevel                  int    `json:"summonerLevel"`
-	Summonername                   string `json:"summonerName"`
-	Teamearlysurrendered           bool   `json:"teamEarlySurrendered"`
-	Teamid                         int    `json:"teamId"`
-	Teamposition                   string `json:"teamPosition"` // 	Both individualPosition and teamPosition are computed by the game server and are different versions of the most likely position played by a player. The individualPosition is the best guess for which position the player actually played in isolation of anything else. The teamPosition is the best guess for which position the player actually played if we add the constraint that each team must have one top player, one jungle, one middle, etc. Generally the recommendation is to use the teamPosition field over the individualPosition field.
-	Timeccingothers                int    `json:"timeCCingOthers"`
-	Timeplayed                     int    `json:"timePlayed"`
-	Totaldamagedealt               int    `json:"totalDamageDealt"`
-	Totaldamagedealttochampions    int    `json:"totalDamageDealtToChampions"`
-	Totaldamageshieldedonteammates int    `json:"totalDamageShieldedOnTeammates"`
-	Totaldamagetaken               int    `json:"totalDamageTaken"`
-	Totalheal                      int    `json:"totalHeal"`
-	Totalhealsonteammates          int    `json:"totalHealsOnTeammates"`
-	Totalminionskilled             int    `json:"totalMinionsKilled"`
-	Totaltimeccdealt               int    `json:"totalTimeCCDealt"`
-	Totaltimespentdead             int    `json:"totalTimeSpentDead"`
-	Totalunitshealed               int    `json:"totalUnitsHealed"`
-	Triplekills                    int    `json:"tripleKills"`
-	Truedamagedealt                int    `json:"trueDamageDealt"`
-	Truedamagedealttochampions     int    `json:"trueDamageDealtToChampions"`
-	Truedamagetaken                int    `json:"trueDamageTaken"`
-	Turretkills                    int    `json:"turretKills"`
-	Turrettakedowns                int    `json:"turretTakedowns"`
-	Turretslost                    int    `json:"turretsLost"`
-	Unrealkills                    int    `json:"unrealKills"`
-	Visionscore                    int    `json:"visionScore"`
-	Visionwardsboughtingame        int    `json:"visionWardsBoughtInGame"`
-	Wardskilled                    int    `json:"wardsKilled"`
-	Wardsplaced                    int    `json:"wardsPlaced"`
-	Win                            bool   `json:"win"`
+	Allinpings                     int                    `bson:"allInPings" json:"allInPings"`
+	Assistmepings                  int                    `bson:"assistMePings" json:"assistMePings"`
+	Assists                        int                    `bson:"assists" json:"assists"`
+	Baitpings                      int                    `bson:"baitPings" json:"baitPings"`
+	Baronkills                     int                    `bson:"baronKills" json:"baronKills"`
+	Basicpings                     int                    `bson:"basicPings" json:"basicPings"`
+	Bountylevel                    int                    `bson:"bountyLevel" json:"bountyLevel"`
+	Challenges                     map[string]interface{} `bson:"challenges" json:"challenges"` // Progress the participant made on challenges during the match, keyed by the challenge's name
+	Champexperience                int                    `bson:"champExperience" json:"champExperience"`
+	Championid                     int                    `bson:"championId" json:"championId"` // Prior to patch 11.4, on Feb 18th, 2021, this field returned invalid championIds. We recommend determining the champion based on the championName field for matches played prior to patch 11.4.
+	Championname                   string                 `bson:"championName" json:"championName"`
+	Championtransform              int                    `bson:"championTransform" json:"championTransform"` // This field is currently only utilized for Kayn's transformations. (Legal values: 0 - None, 1 - Slayer, 2 - Assassin)
+	Champlevel                     int                    `bson:"champLevel" json:"champLevel"`
+	Commandpings                   int                    `bson:"commandPings" json:"commandPings"`
+	Consumablespurchased           int                    `bson:"consumablesPurchased" json:"consumablesPurchased"`
+	Damagedealttobuildings         int                    `bson:"damageDealtToBuildings" json:"damageDealtToBuildings"`
+	Damagedealttoobjectives        int                    `bson:"damageDealtToObjectives" json:"damageDealtToObjectives"`
+	Damagedealttoturrets           int                    `bson:"damageDealtToTurrets" json:"damageDealtToTurrets"`
+	Damageselfmitigated            int                    `bson:"damageSelfMitigated" json:"damageSelfMitigated"`
+	Dangerpings                    int                    `bson:"dangerPings" json:"dangerPings"`
+	Deaths                         int                    `bson:"deaths" json:"deaths"`
+	Detectorwardsplaced            int                    `bson:"detectorWardsPlaced" json:"detectorWardsPlaced"`
+	Doublekills                    int                    `bson:"doubleKills" json:"doubleKills"`
+	Dragonkills                    int                    `bson:"dragonKills" json:"dragonKills"`
+	Eligibleforprogression         bool                   `bson:"eligibleForProgression" json:"eligibleForProgression"`
+	Enemymissingpings              int                    `bson:"enemyMissingPings" json:"enemyMissingPings"`
+	Enemyvisionpings               int                    `bson:"enemyVisionPings" json:"enemyVisionPings"`
+	Firstbloodassist               bool                   `bson:"firstBloodAssist" json:"firstBloodAssist"`
+	Firstbloodkill                 bool                   `bson:"firstBloodKill" json:"firstBloodKill"`
+	Firsttowerassist               bool                   `bson:"firstTowerAssist" json:"firstTowerAssist"`
+	Firsttowerkill                 bool                   `bson:"firstTowerKill" json:"firstTowerKill"`
+	Gameendedinearlysurrender      bool                   `bson:"gameEndedInEarlySurrender" json:"gameEndedInEarlySurrender"`
+	Gameendedinsurrender           bool                   `bson:"gameEndedInSurrender" json:"gameEndedInSurrender"`
+	Getbackpings                   int                    `bson:"getBackPings" json:"getBackPings"`
+	Goldearned                     int                    `bson:"goldEarned" json:"goldEarned"`
+	Goldspent                      int                    `bson:"goldSpent" json:"goldSpent"`
+	Holdpings                      int                    `bson:"holdPings" json:"holdPings"`
+	Individualposition             string                 `bson:"individualPosition" json:"individualPosition"` // Both individualPosition and teamPosition are computed by the game server and are different versions of the most likely position played by a player. The individualPosition is the best guess for which position the player actually played in isolation of anything else. The teamPosition is the best guess for which position the player actually played if we add the constraint that each team must have one top player, one jungle, one middle, etc. Generally the recommendation is to use the teamPosition field over the individualPosition field.
+	Inhibitorkills                 int                    `bson:"inhibitorKills" json:"inhibitorKills"`
+	Inhibitorslost                 int                    `bson:"inhibitorsLost" json:"inhibitorsLost"`
+	Inhibitortakedowns             int                    `bson:"inhibitorTakedowns" json:"inhibitorTakedowns"`
+	Item0                          int                    `bson:"item0" json:"item0"`
+	Item1                          int                    `bson:"item1" json:"item1"`
+	Item2                          int                    `bson:"item2" json:"item2"`
+	Item3                          int                    `bson:"item3" json:"item3"`
+	Item4                          int                    `bson:"item4" json:"item4"`
+	Item5                          int                    `bson:"item5" json:"item5"`
+	Item6                          int                    `bson:"item6" json:"item6"`
+	Itemspurchased                 int                    `bson:"itemsPurchased" json:"itemsPurchased"`
+	Killingsprees                  int                    `bson:"killingSprees" json:"killingSprees"`
+	Kills                          int                    `bson:"kills" json:"kills"`
+	Lane                           string                 `bson:"lane" json:"lane"`
+	Largestcriticalstrike          int                    `bson:"largestCriticalStrike" json:"largestCriticalStrike"`
+	Largestkillingspree            int                    `bson:"largestKillingSpree" json:"largestKillingSpree"`
+	Largestmultikill               int                    `bson:"largestMultiKill" json:"largestMultiKill"`
+	Longesttimespentliving         int                    `bson:"longestTimeSpentLiving" json:"longestTimeSpentLiving"`
+	Magicdamagedealt               int                    `bson:"magicDamageDealt" json:"magicDamageDealt"`
+	Magicdamagedealttochampions    int                    `bson:"magicDamageDealtToChampions" json:"magicDamageDealtToChampions"`
+	Magicdamagetaken               int                    `bson:"magicDamageTaken" json:"magicDamageTaken"`
+	Missions                       Missions               `bson:"missions" json:"missions"`
+	Needvisionpings                int                    `bson:"needVisionPings" json:"needVisionPings"`
+	Neutralminionskilled           int                    `bson:"neutralMinionsKilled" json:"neutralMinionsKilled"`
+	Nexuskills                     int                    `bson:"nexusKills" json:"nexusKills"`
+	Nexuslost                      int                    `bson:"nexusLost" json:"nexusLost"`
+	Nexustakedowns                 int                    `bson:"nexusTakedowns" json:"nexusTakedowns"`
+	Objectivesstolen               int                    `bson:"objectivesStolen" json:"objectivesStolen"`
+	Objectivesstolenassists        int                    `bson:"objectivesStolenAssists" json:"objectivesStolenAssists"`
+	Onmywaypings                   int                    `bson:"onMyWayPings" json:"onMyWayPings"`
+	Participantid                  int                    `bson:"participantId" json:"participantId"`
+	Pentakills                     int                    `bson:"pentaKills" json:"pentaKills"`
+	Perks                          Perk                   `bson:"perks" json:"perks"`
+	Physicaldamagedealt            int                    `bson:"physicalDamageDealt" json:"physicalDamageDealt"`
+	Physicaldamagedealttochampions int                    `bson:"physicalDamageDealtToChampions" json:"physicalDamageDealtToChampions"`
+	Physicaldamagetaken            int                    `bson:"physicalDamageTaken" json:"physicalDamageTaken"`
+	Placement                      int                    `bson:"placement" json:"placement"`           // Only utilized in Arena
+	Playeraugment1                 int                    `bson:"playerAugment1" json:"playerAugment1"` // Only utilized in Arena
+	Playeraugment2                 int                    `bson:"playerAugment2" json:"playerAugment2"`
+	Playeraugment3                 int                    `bson:"playerAugment3" json:"playerAugment3"`
+	Playeraugment4                 int                    `bson:"playerAugment4" json:"playerAugment4"`
+	Playeraugment5                 int                    `bson:"playerAugment5" json:"playerAugment5"`
+	Playeraugment6                 int                    `bson:"playerAugment6" json:"playerAugment6"`
+	Playersubteamid                int                    `bson:"playerSubteamId" json:"playerSubteamId"` // Only utilized in Arena
+	Profileicon                    int                    `bson:"profileIcon" json:"profileIcon"`
+	Pushpings                      int                    `bson:"pushPings" json:"pushPings"`
+	Puuid                          string                 `bson:"puuid" json:"puuid"`
+	Quadrakills                    int                    `bson:"quadraKills" json:"quadraKills"`
+	Retreatpings                   int                    `bson:"retreatPings" json:"retreatPings"`
+	Riotidgamename                 string                 `bson:"riotIdGameName" json:"riotIdGameName"` // Replaces riotIdName, which is deprecated
+	Riotidname                     string                 `bson:"riotIdName" json:"riotIdName"`
+	Riotidtagline                  string                 `bson:"riotIdTagline" json:"riotIdTagline"`
+	Role                           string                 `bson:"role" json:"role"`
+	Sightwardsboughtingame         int                    `bson:"sightWardsBoughtInGame" json:"sightWardsBoughtInGame"`
+	Spell1casts                    int                    `bson:"spell1Casts" json:"spell1Casts"`
+	Spell2casts                    int                    `bson:"spell2Casts" json:"spell2Casts"`
+	Spell3casts                    int                    `bson:"spell3Casts" json:"spell3Casts"`
+	Spell4casts                    int                    `bson:"spell4Casts" json:"spell4Casts"`
+	Subteamplacement               int                    `bson:"subteamPlacement" json:"subteamPlacement"` // Only utilized in Arena
+	Summoner1casts                 int                    `bson:"summoner1Casts" json:"summoner1Casts"`
+	Summoner1id                    int                    `bson:"summoner1Id" json:"summoner1Id"`
+	Summoner2casts                 int                    `bson:"summoner2Casts" json:"summoner2Casts"`
+	Summoner2id                    int                    `bson:"summoner2Id" json:"summoner2Id"`
+	Summonerid                     string                 `bson:"summonerId" json:"summonerId"`
+	Summonerlevel                  int                    `bson:"summonerLevel" json:"summonerLevel"`
+	Summonername                   string                 `bson:"summonerName" json:"summonerName"`
+	Teamearlysurrendered           bool                   `bson:"teamEarlySurrendered" json:"teamEarlySurrendered"`
+	Teamid                         int                    `bson:"teamId" json:"teamId"`
+	Teamposition                   string                 `bson:"teamPosition" json:"teamPosition"` // 	Both individualPosition and teamPosition are computed by the game server and are different versions of the most likely position played by a player. The individualPosition is the best guess for which position the player actually played in isolation of anything else. The teamPosition is the best guess for which position the player actually played if we add the constraint that each team must have one top player, one jungle, one middle, etc. Generally the recommendation is to use the teamPosition field over the individualPosition field.
+	Timeccingothers                int                    `bson:"timeCCingOthers" json:"timeCCingOthers"`
+	Timeplayed                     int                    `bson:"timePlayed" json:"timePlayed"`
+	Totalallyjungleminionskilled   int                    `bson:"totalAllyJungleMinionsKilled" json:"totalAllyJungleMinionsKilled"`
+	Totaldamagedealt               int                    `bson:"totalDamageDealt" json:"totalDamageDealt"`
+	Totaldamagedealttochampions    int                    `bson:"totalDamageDealtToChampions" json:"totalDamageDealtToChampions"`
+	Totaldamageshieldedonteammates int                    `bson:"totalDamageShieldedOnTeammates" json:"totalDamageShieldedOnTeammates"`
+	Totaldamagetaken               int                    `bson:"totalDamageTaken" json:"totalDamageTaken"`
+	Totalenemyjungleminionskilled  int                    `bson:"totalEnemyJungleMinionsKilled" json:"totalEnemyJungleMinionsKilled"`
+	Totalheal                      int                    `bson:"totalHeal" json:"totalHeal"`
+	Totalhealsonteammates          int                    `bson:"totalHealsOnTeammates" json:"totalHealsOnTeammates"`
+	Totalminionskilled             int                    `bson:"totalMinionsKilled" json:"totalMinionsKilled"`
+	Totaltimeccdealt               int                    `bson:"totalTimeCCDealt" json:"totalTimeCCDealt"`
+	Totaltimespentdead             int                    `bson:"totalTimeSpentDead" json:"totalTimeSpentDead"`
+	Totalunitshealed               int                    `bson:"totalUnitsHealed" json:"totalUnitsHealed"`
+	Triplekills                    int                    `bson:"tripleKills" json:"tripleKills"`
+	Truedamagedealt                int                    `bson:"trueDamageDealt" json:"trueDamageDealt"`
+	Truedamagedealttochampions     int                    `bson:"trueDamageDealtToChampions" json:"trueDamageDealtToChampions"`
+	Truedamagetaken                int                    `bson:"trueDamageTaken" json:"trueDamageTaken"`
+	Turretkills                    int                    `bson:"turretKills" json:"turretKills"`
+	Turretslost                    int                    `bson:"turretsLost" json:"turretsLost"`
+	Turrettakedowns                int                    `bson:"turretTakedowns" json:"turretTakedowns"`
+	Unrealkills                    int                    `bson:"unrealKills" json:"unrealKills"`
+	Visionclearedpings             int                    `bson:"visionClearedPings" json:"visionClearedPings"`
+	Visionscore                    int                    `bson:"visionScore" json:"visionScore"`
+	Visionwardsboughtingame        int                    `bson:"visionWardsBoughtInGame" json:"visionWardsBoughtInGame"`
+	Wardskilled                    int                    `bson:"wardsKilled" json:"wardsKilled"`
+	Wardsplaced                    int                    `bson:"wardsPlaced" json:"wardsPlaced"`
+	Win                            bool                   `bson:"win" json:"win"`
+}
+
+type Missions struct {
+	Playerscore0  float64 `bson:"playerScore0" json:"playerScore0"`
+	Playerscore1  float64 `bson:"playerScore1" json:"playerScore1"`
+	Playerscore2  float64 `bson:"playerScore2" json:"playerScore2"`
+	Playerscore3  float64 `bson:"playerScore3" json:"playerScore3"`
+	Playerscore4  float64 `bson:"playerScore4" json:"playerScore4"`
+	Playerscore5  float64 `bson:"playerScore5" json:"playerScore5"`
+	Playerscore6  float64 `bson:"playerScore6" json:"playerScore6"`
+	Playerscore7  float64 `bson:"playerScore7" json:"playerScore7"`
+	Playerscore8  float64 `bson:"playerScore8" json:"playerScore8"`
+	Playerscore9  float64 `bson:"playerScore9" json:"playerScore9"`
+	Playerscore10 float64 `bson:"playerScore10" json:"playerScore10"`
+	Playerscore11 float64 `bson:"playerScore11" json:"playerScore11"`
 }
 
 type Perk struct {
-	StatPerks PerkStats   `json:"statPerks"`
-	Styles    []PerkStyle `json:"styles"`
+	StatPerks PerkStats   `bson:"statPerks" json:"statPerks"`
+	Styles    []PerkStyle `bson:"styles" json:"styles"`
 }
 
 type PerkStats struct {
-	Defense int `json:"defense"`
-	Flex    int `json:"flex"`
-	Offense int `json:"offense"`
+	Defense int `bson:"defense" json:"defense"`
+	Flex    int `bson:"flex" json:"flex"`
+	Offense int `bson:"offense" json:"offense"`
 }
 
 type PerkStyle struct {
-	Description string               `json:"description"`
-	Selections  []PerkStyleSelection `json:"selections"`
-	Style       int                  `json:"style"`
+	Description string               `bson:"description" json:"description"`
+	Selections  []PerkStyleSelection `bson:"selections" json:"selections"`
+	Style       int                  `bson:"style" json:"style"`
 }
 
 type PerkStyleSelection struct {
-	Perk int `json:"perk"`
-	Var1 int `json:"var1"`
-	Var2 int `json:"var2"`
-	Var3 int `json:"var3"`
+	Perk int `bson:"perk" json:"perk"`
+	Var1 int `bson:"var1" json:"var1"`
+	Var2 int `bson:"var2" json:"var2"`
+	Var3 int `bson:"var3" json:"var3"`
 }
 
 type Team struct {
-	Bans       []Ban     `json:"bans"`
-	Objectives Objective `json:"objectives"`
-	Teamid     int       `json:"teamId"`
-	Win        bool      `json:"win"`
+	Bans       []Ban           `bson:"bans" json:"bans"`
+	Feats      map[string]Feat `bson:"feats,omitempty" json:"feats"` // Feats of Strength, e.g. FIRST_BLOOD, FIRST_TURRET, EPIC_MONSTER_KILL
+	Objectives Objectives      `bson:"objectives" json:"objectives"`
+	Teamid     int             `bson:"teamId" json:"teamId"`
+	Win        bool            `bson:"win" json:"win"`
 }
 
 type Ban struct {
-	ChampionId int `json:"championId"`
-	PickTurn   int `json:"pickTurn"`
+	ChampionId int `bson:"championId" json:"championId"`
+	PickTurn   int `bson:"pickTurn" json:"pickTurn"`
+}
+
+type Feat struct {
+	FeatState int `bson:"featState" json:"featState"`
 }
 
 type Objectives struct {
-	Baron      Objective `json:"baron"`
-	Champion   Objective `json:"champion"`
-	Dragon     Objective `json:"dragon"`
-	Inhibitor  Objective `json:"inhibitor"`
-	Riftherald Objective `json:"riftHerald"`
-	Tower      Objective `json:"tower"`
+	Atakhan    Objective `bson:"atakhan" json:"atakhan"`
+	Baron      Objective `bson:"baron" json:"baron"`
+	Champion   Objective `bson:"champion" json:"champion"`
+	Dragon     Objective `bson:"dragon" json:"dragon"`
+	Horde      Objective `bson:"horde" json:"horde"` // Voidgrubs
+	Inhibitor  Objective `bson:"inhibitor" json:"inhibitor"`
+	Riftherald Objective `bson:"riftHerald" json:"riftHerald"`
+	Tower      Objective `bson:"tower" json:"tower"`
 }
 
 type Objective struct {
-	First bool `json:"first"`
-	Kills int  `json:"kills"`
+	First bool `bson:"first" json:"first"`
+	Kills int  `bson:"kills" json:"kills"`
+}
+
+// GetMatchID returns the id of the match
+func (m *Match) GetMatchID() string {
+	return m.MetaData.MatchID
+}
+
+// GetParticipants returns the PUUIDs of the match's participants
+func (m *Match) GetParticipants() []string {
+	return m.MetaData.Participants
+}
+
+// Patch returns the major and minor version of the game the match was played on, e.g. "14.1" for game version "14.1.555.1234"
+func (m *Match) Patch() string {
+	parts := strings.SplitN(m.Info.GameVersion, ".", 3)
+	if len(parts) < 2 {
+		return m.Info.GameVersion
+	}
+	return parts[0] + "." + parts[1]
 }
@@ -0,0 +1,64 @@
+package types
+
+// FeaturedGames reflects the FeaturedGamesDTO object of the spectator endpoint according to riot api documentation
+type FeaturedGames struct {
+	GameList              []FeaturedGameInfo `json:"gameList"`
+	ClientRefreshInterval int64              `json:"clientRefreshInterval"` // The suggested interval to wait before requesting FeaturedGames again
+}
+
+type FeaturedGameInfo struct {
+	GameID            int64                 `json:"gameId"`
+	GameMode          string                `json:"gameMode"`
+	GameType          string                `json:"gameType"`
+	GameLength        int64                 `json:"gameLength"`
+	GameStartTime     int64                 `json:"gameStartTime"`
+	GameQueueConfigID int                   `json:"gameQueueConfigId"`
+	MapID             int                   `json:"mapId"`
+	PlatformID        string                `json:"platformId"`
+	BannedChampions   []BannedChampion      `json:"bannedChampions"`
+	Participants      []FeaturedParticipant `json:"participants"`
+}
+
+type BannedChampion struct {
+	ChampionID int `json:"championId"`
+	PickTurn   int `json:"pickTurn"`
+	TeamID     int `json:"teamId"`
+}
+
+type FeaturedParticipant struct {
+	Puuid         string `json:"puuid"`
+	RiotID        string `json:"riotId"`
+	ChampionID    int    `json:"championId"`
+	ProfileIconID int    `json:"profileIconId"`
+	Spell1ID      int    `json:"spell1Id"`
+	Spell2ID      int    `json:"spell2Id"`
+	TeamID        int    `json:"teamId"`
+	Bot           bool   `json:"bot"`
+}
+
+// CurrentGameInfo reflects the CurrentGameInfo object of the spectator endpoint according to riot api documentation,
+// i.e. the game a player is currently playing
+type CurrentGameInfo struct {
+	GameID            int64                    `json:"gameId"`
+	GameMode          string                   `json:"gameMode"`
+	GameType          string                   `json:"gameType"`
+	GameLength        int64                    `json:"gameLength"`
+	GameStartTime     int64                    `json:"gameStartTime"`
+	GameQueueConfigID int                      `json:"gameQueueConfigId"`
+	MapID             int                      `json:"mapId"`
+	PlatformID        string                   `json:"platformId"`
+	BannedChampions   []BannedChampion         `json:"bannedChampions"`
+	Participants      []CurrentGameParticipant `json:"participants"`
+}
+
+type CurrentGameParticipant struct {
+	Puuid         string `json:"puuid"`
+	RiotID        string `json:"riotId"`
+	SummonerID    string `json:"summonerId"`
+	ChampionID    int    `json:"championId"`
+	ProfileIconID int    `json:"profileIconId"`
+	Spell1ID      int    `json:"spell1Id"`
+	Spell2ID      int    `json:"spell2Id"`
+	TeamID        int    `json:"teamId"`
+	Bot           bool   `json:"bot"`
+}
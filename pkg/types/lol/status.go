@@ -0,0 +1,61 @@
+package types
+
+// Values of Status.MaintenanceStatus and Status.IncidentSeverity according to lol-status-v4
+const (
+	MaintenanceScheduled  = "scheduled"
+	MaintenanceInProgress = "in_progress"
+	MaintenanceComplete   = "complete"
+
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// PlatformData reflects the PlatformDataDto object of lol-status-v4 according to riot api documentation
+type PlatformData struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Locales      []string `json:"locales"`
+	Maintenances []Status `json:"maintenances"`
+	Incidents    []Status `json:"incidents"`
+}
+
+type Status struct {
+	ID                int       `json:"id"`
+	MaintenanceStatus string    `json:"maintenance_status"`
+	IncidentSeverity  string    `json:"incident_severity"`
+	Titles            []Content `json:"titles"`
+	Updates           []Update  `json:"updates"`
+	CreatedAt         string    `json:"created_at"`
+	ArchiveAt         string    `json:"archive_at"`
+	UpdatedAt         string    `json:"updated_at"`
+	Platforms         []string  `json:"platforms"`
+}
+
+type Content struct {
+	Locale  string `json:"locale"`
+	Content string `json:"content"`
+}
+
+type Update struct {
+	ID               int       `json:"id"`
+	Author           string    `json:"author"`
+	Publish          bool      `json:"publish"`
+	PublishLocations []string  `json:"publish_locations"`
+	Translations     []Content `json:"translations"`
+	CreatedAt        string    `json:"created_at"`
+	UpdatedAt        string    `json:"updated_at"`
+}
+
+// Title returns the english title of a status if present, otherwise the first one available
+func (s Status) Title() string {
+	for _, t := range s.Titles {
+		if t.Locale == "en_US" {
+			return t.Content
+		}
+	}
+	if len(s.Titles) > 0 {
+		return s.Titles[0].Content
+	}
+	return ""
+}
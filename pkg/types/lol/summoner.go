@@ -1,12 +1,22 @@
 package types
 
-// Summoner DTO according to v4
+// Summoner DTO according to v4.
+// NameHistory is not part of the api, it records every name a stored player has been observed with
 type Summoner struct {
-	AccountId     string `json:"accountId"`
-	ProfileIconId int    `json:"profileIconId"`
-	RevisionDate  int64  `json:"revisionDate"`
-	Name          string `json:"name"`
-	Id            string `json:"id"`
-	Puuid         string `json:"puuid"`
-	SummonerLevel int64  `json:"summonerLevel"`
+	AccountId      string       `bson:"accountId" json:"accountId"`
+	ProfileIconId  int          `bson:"profileIconId" json:"profileIconId"`
+	RevisionDate   int64        `bson:"revisionDate" json:"revisionDate"`
+	Name           string       `bson:"name" json:"name"`
+	Id             string       `bson:"id" json:"id"`
+	Puuid          string       `bson:"puuid" json:"puuid"`
+	SummonerLevel  int64        `bson:"summonerLevel" json:"summonerLevel"`
+	NameHistory    []NameChange `bson:"nameHistory,omitempty" json:"nameHistory,omitempty"`
+	SchemaVersion  int          `bson:"schemaVersion" json:"schemaVersion,omitempty"`
+	CrawlerVersion string       `bson:"crawlerVersion,omitempty" json:"crawlerVersion,omitempty"`
+}
+
+// NameChange records the name of a player and since when (unix milliseconds) the name has been observed
+type NameChange struct {
+	Name       string `bson:"name" json:"name"`
+	ObservedAt int64  `bson:"observedAt" json:"observedAt"`
 }
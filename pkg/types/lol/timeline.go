@@ -0,0 +1,73 @@
+package types
+
+// Timeline reflects the TimelineDto object of match-v5 according to riot api documentation.
+// Frames are taken every Info.FrameInterval milliseconds and hold the events since the previous frame
+type Timeline struct {
+	MetaData MetaData     `json:"metadata"`
+	Info     TimelineInfo `json:"info"`
+}
+
+type TimelineInfo struct {
+	EndOfGameResult string                `json:"endOfGameResult"`
+	FrameInterval   int64                 `json:"frameInterval"`
+	GameID          int64                 `json:"gameId"`
+	Participants    []TimelineParticipant `json:"participants"`
+	Frames          []Frame               `json:"frames"`
+}
+
+type TimelineParticipant struct {
+	ParticipantID int    `json:"participantId"`
+	Puuid         string `json:"puuid"`
+}
+
+type Frame struct {
+	Timestamp         int64                       `json:"timestamp"`
+	Events            []Event                     `json:"events"`
+	ParticipantFrames map[string]ParticipantFrame `json:"participantFrames"` // Keyed by the participantId
+}
+
+type ParticipantFrame struct {
+	ParticipantID            int       `json:"participantId"`
+	Level                    int       `json:"level"`
+	XP                       int       `json:"xp"`
+	CurrentGold              int       `json:"currentGold"`
+	TotalGold                int       `json:"totalGold"`
+	GoldPerSecond            int       `json:"goldPerSecond"`
+	MinionsKilled            int       `json:"minionsKilled"`
+	JungleMinionsKilled      int       `json:"jungleMinionsKilled"`
+	TimeEnemySpentControlled int       `json:"timeEnemySpentControlled"`
+	Position                 *Position `json:"position,omitempty"`
+}
+
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Event is any event of a frame, e.g. CHAMPION_KILL or ITEM_PURCHASED. Which fields are set depends on the Type
+type Event struct {
+	Type                    string    `json:"type"`
+	Timestamp               int64     `json:"timestamp"`
+	RealTimestamp           int64     `json:"realTimestamp,omitempty"`
+	ParticipantID           int       `json:"participantId,omitempty"`
+	KillerID                int       `json:"killerId,omitempty"`
+	VictimID                int       `json:"victimId,omitempty"`
+	AssistingParticipantIDs []int     `json:"assistingParticipantIds,omitempty"`
+	CreatorID               int       `json:"creatorId,omitempty"`
+	TeamID                  int       `json:"teamId,omitempty"`
+	KillerTeamID            int       `json:"killerTeamId,omitempty"`
+	ItemID                  int       `json:"itemId,omitempty"`
+	SkillSlot               int       `json:"skillSlot,omitempty"`
+	LevelUpType             string    `json:"levelUpType,omitempty"`
+	WardType                string    `json:"wardType,omitempty"`
+	KillType                string    `json:"killType,omitempty"`
+	MonsterType             string    `json:"monsterType,omitempty"`
+	MonsterSubType          string    `json:"monsterSubType,omitempty"`
+	BuildingType            string    `json:"buildingType,omitempty"`
+	TowerType               string    `json:"towerType,omitempty"`
+	LaneType                string    `json:"laneType,omitempty"`
+	Bounty                  int       `json:"bounty,omitempty"`
+	Level                   int       `json:"level,omitempty"`
+	Position                *Position `json:"position,omitempty"`
+	WinningTeam             int       `json:"winningTeam,omitempty"`
+}
@@ -0,0 +1,23 @@
+package types
+
+// League reflects the LeagueListDTO object of tft-league-v1 according to riot api documentation
+type League struct {
+	LeagueID string       `json:"leagueId"`
+	Entries  []LeagueItem `json:"entries"`
+	Tier     string       `json:"tier"`
+	Name     string       `json:"name"`
+	Queue    string       `json:"queue"`
+}
+
+type LeagueItem struct {
+	Puuid        string `json:"puuid"`
+	SummonerID   string `json:"summonerId"`
+	Rank         string `json:"rank"`
+	LeaguePoints int    `json:"leaguePoints"`
+	Wins         int    `json:"wins"` // First placement
+	Losses       int    `json:"losses"`
+	FreshBlood   bool   `json:"freshBlood"`
+	Inactive     bool   `json:"inactive"`
+	Veteran      bool   `json:"veteran"`
+	HotStreak    bool   `json:"hotStreak"`
+}
@@ -0,0 +1,87 @@
+package types
+
+// SchemaVersion is the version of the types of this package, i.e. of the documents stored based on them.
+// It needs to be incremented whenever a change to the types requires stored documents to be migrated
+const SchemaVersion = 1
+
+// Match reflects the MatchDto object of tft-match-v1 according to riot api documentation
+type Match struct {
+	MetaData       MetaData `bson:"metadata" json:"metadata"`
+	Info           Info     `bson:"info" json:"info"`
+	SchemaVersion  int      `bson:"schemaVersion" json:"schemaVersion,omitempty"`
+	CrawlerVersion string   `bson:"crawlerVersion,omitempty" json:"crawlerVersion,omitempty"`
+}
+
+type MetaData struct {
+	DataVersion  string   `bson:"data_version" json:"data_version"`
+	MatchID      string   `bson:"match_id" json:"match_id"`
+	Participants []string `bson:"participants" json:"participants"` // A list of participant PUUIDs
+}
+
+type Info struct {
+	GameCreation    int64         `bson:"gameCreation" json:"gameCreation"`
+	GameID          int64         `bson:"gameId" json:"gameId"`
+	GameDatetime    int64         `bson:"game_datetime" json:"game_datetime"` // Unix timestamp
+	GameLength      float64       `bson:"game_length" json:"game_length"`     // Game length in seconds
+	GameVersion     string        `bson:"game_version" json:"game_version"`
+	GameVariation   string        `bson:"game_variation" json:"game_variation"` // Only present for matches in TFT Set 3
+	EndOfGameResult string        `bson:"endOfGameResult" json:"endOfGameResult"`
+	MapID           int           `bson:"mapId" json:"mapId"`
+	Participants    []Participant `bson:"participants" json:"participants"`
+	QueueID         int           `bson:"queue_id" json:"queue_id"`
+	TftGameType     string        `bson:"tft_game_type" json:"tft_game_type"`
+	TftSetCoreName  string        `bson:"tft_set_core_name" json:"tft_set_core_name"`
+	TftSetNumber    int           `bson:"tft_set_number" json:"tft_set_number"`
+}
+
+type Participant struct {
+	Augments             []string  `bson:"augments" json:"augments"`
+	Companion            Companion `bson:"companion" json:"companion"`
+	GoldLeft             int       `bson:"gold_left" json:"gold_left"`   // Gold left after participant was eliminated
+	LastRound            int       `bson:"last_round" json:"last_round"` // The round the participant was eliminated in. Note: If the player was eliminated in stage 2-1 their last_round would be 5
+	Level                int       `bson:"level" json:"level"`           // Participant Little Legend level. Note: This is not the number of active units
+	Placement            int       `bson:"placement" json:"placement"`   // Participant placement upon elimination
+	PlayersEliminated    int       `bson:"players_eliminated" json:"players_eliminated"`
+	Puuid                string    `bson:"puuid" json:"puuid"`
+	RiotIDGameName       string    `bson:"riotIdGameName" json:"riotIdGameName"`
+	RiotIDTagline        string    `bson:"riotIdTagline" json:"riotIdTagline"`
+	TimeEliminated       float64   `bson:"time_eliminated" json:"time_eliminated"` // The number of seconds before the participant was eliminated
+	TotalDamageToPlayers int       `bson:"total_damage_to_players" json:"total_damage_to_players"`
+	Traits               []Trait   `bson:"traits" json:"traits"` // A complete list of traits for the participant's active units
+	Units                []Unit    `bson:"units" json:"units"`   // A list of active units for the participant
+	Win                  bool      `bson:"win" json:"win"`
+}
+
+type Companion struct {
+	ContentID string `bson:"content_ID" json:"content_ID"`
+	ItemID    int    `bson:"item_ID" json:"item_ID"`
+	SkinID    int    `bson:"skin_ID" json:"skin_ID"`
+	Species   string `bson:"species" json:"species"`
+}
+
+type Trait struct {
+	Name        string `bson:"name" json:"name"`
+	NumUnits    int    `bson:"num_units" json:"num_units"`
+	Style       int    `bson:"style" json:"style"` // (Legal values: 0 - No style, 1 - Bronze, 2 - Silver, 3 - Gold, 4 - Chromatic)
+	TierCurrent int    `bson:"tier_current" json:"tier_current"`
+	TierTotal   int    `bson:"tier_total" json:"tier_total"`
+}
+
+type Unit struct {
+	ItemNames   []string `bson:"itemNames" json:"itemNames"`
+	CharacterID string   `bson:"character_id" json:"character_id"`
+	Chosen      string   `bson:"chosen" json:"chosen"` // If a unit is chosen as part of the Fates set mechanic, the chosen trait will be indicated by this field. Otherwise this field is excluded from the response
+	Name        string   `bson:"name" json:"name"`
+	Rarity      int      `bson:"rarity" json:"rarity"`
+	Tier        int      `bson:"tier" json:"tier"` // Unit tier
+}
+
+// GetMatchID returns the id of the match
+func (m *Match) GetMatchID() string {
+	return m.MetaData.MatchID
+}
+
+// GetParticipants returns the PUUIDs of the match's participants
+func (m *Match) GetParticipants() []string {
+	return m.MetaData.Participants
+}
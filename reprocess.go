@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"go-league-crawler/pkg/storage"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reprocess decodes the archived raw api responses into the current types and replaces the typed documents with them,
+// so that schema upgrades do not require to crawl again
+func reprocess(args []string) error {
+	fs := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	mongoConfig := addMongoFlags(fs)
+	kindPtr := fs.String("kind", storage.KIND_MATCH, "Kind of raw documents to reprocess: match, player, tft-match or tft-player")
+	fs.Parse(args)
+
+	mm, err := mongoConfig.manager()
+	if err != nil {
+		return err
+	}
+	defer mm.Close()
+	if err := mm.Init(); err != nil {
+		return err
+	}
+	n, err := storage.Reprocess(mm, *kindPtr)
+	log.Infof("Reprocessed %d raw documents of kind %v", n, *kindPtr)
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"go-league-crawler/pkg/crawler"
+	"go-league-crawler/pkg/riot"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// retryFailed re-drives the dead letters of a crawl, e.g. after an outage or a rotation of the api key.
+// Documents kept in a dead letter are stored again, all others are requested again and stored.
+// Dead letters that fail again stay in the file with their attempts and error updated
+func retryFailed(args []string) error {
+	fs := flag.NewFlagSet("retry-failed", flag.ExitOnError)
+	mongoConfig := addMongoFlags(fs)
+	storages := storagesFlag(fs, "storage", STORAGE_MONGO, "Where to store the re-driven matches and players, given like -storage of the crawler")
+	pathPtr := fs.String("dead-letters", deadLetters, "File of the dead letters to re-drive")
+	platformPtr := fs.String("pl", platform, "Platform the dead letters have been crawled from, dead letters of other platforms are kept")
+	kindPtr := fs.String("kind", "", "Only re-drive dead letters of this kind: match, player, tft-match or tft-player (empty re-drives all kinds)")
+	fs.Parse(args)
+
+	dl := crawler.NewDeadLetters(*pathPtr)
+	letters, err := dl.Load()
+	if err != nil {
+		return err
+	}
+	if len(letters) == 0 {
+		log.Infof("No dead letters in %v", *pathPtr)
+		return nil
+	}
+	dbm, err := openStorages(storages.values(), mongoConfig, 0, 0, nil)
+	if closer, ok := dbm.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err != nil {
+		return err
+	}
+	client, err := riot.NewClient(*platformPtr, limiter)
+	if err != nil {
+		return err
+	}
+	c, err := crawler.NewCrawler(dbm, client, "", 1)
+	if err != nil {
+		return err
+	}
+
+	remaining := []crawler.DeadLetter{}
+	redriven := 0
+	for _, letter := range letters {
+		if (*kindPtr != "" && letter.Kind != *kindPtr) || (letter.Platform != "" && letter.Platform != c.Platform()) {
+			remaining = append(remaining, letter)
+			continue
+		}
+		if err := c.Redrive(&letter); err != nil {
+			log.Warnf("Could not re-drive %v %v: %v", letter.Kind, letter.ID, err)
+			remaining = append(remaining, letter)
+			continue
+		}
+		log.Infof("Re-drove %v %v", letter.Kind, letter.ID)
+		redriven++
+	}
+	log.Infof("Re-drove %d dead letters, %d remain in %v", redriven, len(remaining), *pathPtr)
+	return dl.Replace(remaining)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"bytes"
+	"go-league-crawler/pkg/storage"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRawDocument(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	for _, compression := range []string{storage.GZIP, storage.ZSTD} {
+		doc, err := storage.NewRawDocument(storage.KIND_MATCH, "EUW1_5460889665", compression, jsonMatch)
+		if err != nil {
+			t.Fatalf("Error at compressing test match (%v): %v", compression, err)
+		}
+		if len(doc.Data) >= len(jsonMatch) {
+			t.Errorf("Test match has not been compressed (%v): %d bytes", compression, len(doc.Data))
+		}
+		data, err := doc.Bytes()
+		if err != nil {
+			t.Fatalf("Error at decompressing test match (%v): %v", compression, err)
+		}
+		if !bytes.Equal(data, jsonMatch) {
+			t.Errorf("Raw test match has not been kept exactly (%v)", compression)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"fmt"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"testing"
+)
+
+// failingManager fails every write
+type failingManager struct{}
+
+func (failingManager) InsertMatch(match types.Match) error {
+	return fmt.Errorf("unavailable")
+}
+
+func (failingManager) InsertPlayer(player types.Summoner) error {
+	return fmt.Errorf("unavailable")
+}
+
+// countingManager counts its writes
+type countingManager struct {
+	matches, players int
+}
+
+func (cm *countingManager) InsertMatch(match types.Match) error {
+	cm.matches++
+	return nil
+}
+
+func (cm *countingManager) InsertPlayer(player types.Summoner) error {
+	cm.players++
+	return nil
+}
+
+func TestCompositeManager(t *testing.T) {
+	primary := &countingManager{}
+	cm, err := storage.NewCompositeManager(
+		storage.Sink{Name: "primary", Policy: storage.REQUIRED, DBManager: primary},
+		storage.Sink{Name: "archive", Policy: storage.BEST_EFFORT, DBManager: failingManager{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.InsertMatch(types.Match{}); err != nil {
+		t.Errorf("Failing best-effort sink failed the write: %v", err)
+	}
+	if err := cm.InsertPlayer(types.Summoner{}); err != nil {
+		t.Errorf("Failing best-effort sink failed the write: %v", err)
+	}
+	if primary.matches != 1 || primary.players != 1 {
+		t.Errorf("Expected the required sink to get 1 match and 1 player, got %d and %d", primary.matches, primary.players)
+	}
+	stats := cm.Stats()
+	if stats[0].Writes != 2 || stats[0].Errors != 0 {
+		t.Errorf("Unexpected stats of the required sink: %+v", stats[0])
+	}
+	if stats[1].Writes != 2 || stats[1].Errors != 2 || stats[1].LastError != "unavailable" {
+		t.Errorf("Unexpected stats of the best-effort sink: %+v", stats[1])
+	}
+
+	cm, err = storage.NewCompositeManager(
+		storage.Sink{Name: "primary", Policy: storage.BEST_EFFORT, DBManager: primary},
+		storage.Sink{Name: "archive", Policy: storage.REQUIRED, DBManager: failingManager{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.InsertMatch(types.Match{}); err == nil {
+		t.Errorf("Expected failing required sink to fail the write")
+	}
+	if err := cm.InsertClashTeam(types.ClashTeam{}); err == nil {
+		t.Errorf("Expected clash team to fail without a sink supporting clash")
+	}
+	if storage.Supports(cm, storage.CAPABILITY_CLASH) || storage.Supports(cm, storage.CAPABILITY_READ) {
+		t.Errorf("Expected the capabilities of the sinks to be reported, not the ones of the composite manager")
+	}
+
+	cm, err = storage.NewCompositeManager(
+		storage.Sink{Name: "primary", Policy: storage.REQUIRED, DBManager: primary},
+		storage.Sink{Name: "memory", Policy: storage.BEST_EFFORT, DBManager: storage.NewMemoryManager()},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !storage.Supports(cm, storage.CAPABILITY_CLASH) || !storage.Supports(cm, storage.CAPABILITY_ARCHIVE) {
+		t.Errorf("Expected the capabilities of the memory sink to be reported")
+	}
+}
@@ -0,0 +1,430 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-league-crawler/pkg/crawler"
+	"go-league-crawler/pkg/riot"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// fakeRiotAPI serves a single match, which every player has played, and a summoner for every puuid.
+// Every player is registered for the clash team "team", whose roster are the first three participants of the match
+func fakeRiotAPI(t *testing.T) (*httptest.Server, string) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatal(err)
+	}
+	matchID := match.MetaData.MatchID
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasPrefix(path, "/lol/summoner/v4/summoners/by-name/"):
+			json.NewEncoder(w).Encode(types.Summoner{Puuid: match.MetaData.Participants[0]})
+		case strings.HasPrefix(path, "/lol/summoner/v4/summoners/by-puuid/"):
+			puuid := strings.TrimPrefix(path, "/lol/summoner/v4/summoners/by-puuid/")
+			json.NewEncoder(w).Encode(types.Summoner{Id: puuid, Puuid: puuid})
+		case strings.HasPrefix(path, "/lol/summoner/v4/summoners/"):
+			id := strings.TrimPrefix(path, "/lol/summoner/v4/summoners/")
+			json.NewEncoder(w).Encode(types.Summoner{Id: id, Puuid: id})
+		case strings.HasPrefix(path, "/lol/clash/v1/players/by-summoner/"):
+			json.NewEncoder(w).Encode([]types.ClashPlayer{{TeamID: "team"}})
+		case path == "/lol/clash/v1/teams/team":
+			team := types.ClashTeam{ID: "team"}
+			for _, p := range match.MetaData.Participants[:3] {
+				team.Players = append(team.Players, types.ClashPlayer{SummonerID: p})
+			}
+			json.NewEncoder(w).Encode(team)
+		case strings.HasPrefix(path, "/lol/match/v5/matches/by-puuid/"):
+			if r.URL.Query().Get("start") == "0" {
+				json.NewEncoder(w).Encode([]string{matchID})
+			} else {
+				w.Write([]byte(`[]`))
+			}
+		case path == "/lol/match/v5/matches/"+matchID:
+			w.Write(jsonMatch)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server, matchID
+}
+
+func TestCrawlerHooks(t *testing.T) {
+	server, matchID := fakeRiotAPI(t)
+	defer server.Close()
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1), riot.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memory := storage.NewMemoryManager()
+	var players int32
+	c, err := crawler.NewCrawler(memory, client, "start", 2,
+		crawler.WithMinNumberOfMatches(1),
+		crawler.WithOnMatch(func(ctx context.Context, match *types.Match) error {
+			match.Info.GameName = "enriched"
+			return nil
+		}),
+		crawler.WithOnPlayer(func(ctx context.Context, player *types.Summoner) error {
+			atomic.AddInt32(&players, 1)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	match, err := memory.GetMatch(matchID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Info.GameName != "enriched" {
+		t.Errorf("The match hook did not enrich the stored match")
+	}
+	if atomic.LoadInt32(&players) == 0 {
+		t.Errorf("The player hook has not been called")
+	}
+
+	// A filter rejecting every match leaves nothing but the players to store
+	memory = storage.NewMemoryManager()
+	errors := 0
+	c, err = crawler.NewCrawler(memory, client, "start", 2,
+		crawler.WithMinNumberOfPlayers(1),
+		crawler.WithMatchFilter(func(match *types.Match) bool { return false }),
+		crawler.WithOnError(func(ctx context.Context, kind string, id string, err error) { errors++ }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	counts := memory.Counts()
+	if counts[storage.MATCH_COLLECTION] != 0 || counts[storage.PLAYER_COLLECTION] == 0 {
+		t.Errorf("Unexpected documents after filtering every match: %v", counts)
+	}
+	if errors != 0 {
+		t.Errorf("Filtered matches should not be reported as errors")
+	}
+
+	// Match hooks cannot be used when crawling tft
+	_, err = crawler.NewCrawler(memory, client, "start", 2,
+		crawler.WithOnMatch(func(ctx context.Context, match *types.Match) error { return nil }),
+		crawler.WithTFT(),
+	)
+	if err == nil {
+		t.Errorf("Match hooks should be rejected when crawling tft")
+	}
+}
+
+func TestCrawlerRequeues(t *testing.T) {
+	server, _ := fakeRiotAPI(t)
+	defer server.Close()
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1), riot.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A player the hook requeues every time is given up on after the limit instead of being crawled forever
+	memory := storage.NewMemoryManager()
+	var mu sync.Mutex
+	calls := map[string]int{}
+	givenUp := map[string]bool{}
+	c, err := crawler.NewCrawler(memory, client, "start", 2,
+		crawler.WithMinNumberOfPlayers(1),
+		crawler.WithMaxRequeues(2),
+		crawler.WithOnPlayer(func(ctx context.Context, player *types.Summoner) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[player.Puuid]++
+			return crawler.ErrRequeue
+		}),
+		crawler.WithOnError(func(ctx context.Context, kind string, id string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			givenUp[id] = errors.Is(err, crawler.ErrTooManyRequeues)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		c.Start()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		c.Stop()
+		t.Fatal("The crawler kept requeueing the players")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(givenUp) == 0 {
+		t.Errorf("Expected a player to be given up on")
+	}
+	for player, ok := range givenUp {
+		if !ok || calls[player] != 3 {
+			t.Errorf("Expected player %v to be given up on after 2 requeues, got %d calls (%v)", player, calls[player], ok)
+		}
+	}
+	if n := memory.Counts()[storage.PLAYER_COLLECTION]; n != 0 {
+		t.Errorf("Requeued players should not be stored, got %d", n)
+	}
+}
+
+func TestCrawlerClash(t *testing.T) {
+	server, matchID := fakeRiotAPI(t)
+	defer server.Close()
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1), riot.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The team of the start player is registered before their matches are crawled, so that the first match is linked to it
+	memory := storage.NewMemoryManager()
+	c, err := crawler.NewCrawler(memory, client, "start", 2, crawler.WithClash(), crawler.WithMinNumberOfMatches(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	team, ok := memory.ClashTeam("team")
+	if !ok {
+		t.Fatal("The clash team has not been stored")
+	}
+	if len(team.Matches) != 1 || team.Matches[0] != matchID {
+		t.Errorf("Expected the match to be linked to the clash team, got %v", team.Matches)
+	}
+
+	// A match that could not be stored is not linked
+	failing := failingMatches{storage.NewMemoryManager()}
+	c, err = crawler.NewCrawler(failing, client, "start", 2,
+		crawler.WithClash(),
+		crawler.WithMinNumberOfMatches(1),
+		crawler.WithOnError(func(ctx context.Context, kind string, id string, err error) { c.Stop() }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	if team, ok := failing.ClashTeam("team"); !ok || len(team.Matches) != 0 {
+		t.Errorf("Expected no match to be linked to the clash team, got %v", team.Matches)
+	}
+}
+
+// failingMatches is a MemoryManager that is unable to store matches
+type failingMatches struct {
+	*storage.MemoryManager
+}
+
+func (fm failingMatches) InsertMatch(match types.Match) error {
+	return errors.New("matches unavailable")
+}
+
+func TestCrawlerTFTSeeds(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		mu.Lock()
+		requested[path] = true
+		mu.Unlock()
+		switch {
+		case path == "/tft/league/v1/challenger":
+			w.Write([]byte(`{"entries":[{"summonerId":"s1"}]}`))
+		case path == "/tft/summoner/v1/summoners/by-name/start":
+			w.Write([]byte(`{"puuid":"start"}`))
+		case strings.HasPrefix(path, "/tft/summoner/v1/summoners/by-puuid/"):
+			json.NewEncoder(w).Encode(types.Summoner{Puuid: strings.TrimPrefix(path, "/tft/summoner/v1/summoners/by-puuid/")})
+		case path == "/tft/summoner/v1/summoners/s1":
+			w.Write([]byte(`{"id":"s1","puuid":"seed"}`))
+		case strings.HasPrefix(path, "/tft/match/v1/matches/by-puuid/"):
+			w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1), riot.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Challenger entries without a puuid are resolved by their summoner id
+	c, err := crawler.NewCrawler(storage.NewMemoryManager(), client, "start", 2, crawler.WithTFT(), crawler.WithMinNumberOfPlayers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		c.Start()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		c.Stop()
+		<-done
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !requested["/tft/match/v1/matches/by-puuid/seed/ids"] {
+		t.Errorf("Expected the seeded player to be crawled, requested %v", requested)
+	}
+}
+
+func TestCrawlerStoredMatches(t *testing.T) {
+	server, matchID := fakeRiotAPI(t)
+	defer server.Close()
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1), riot.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	match, err := client.GetMatch(matchID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The participants of a match stored by a previous run are still crawled
+	memory := storage.NewMemoryManager()
+	if err := memory.InsertMatch(*match); err != nil {
+		t.Fatal(err)
+	}
+	c, err := crawler.NewCrawler(memory, client, "start", 2, crawler.WithMinNumberOfPlayers(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	if n := memory.Counts()[storage.PLAYER_COLLECTION]; n < 3 {
+		t.Errorf("Expected the participants of the stored match to be crawled, got %d players", n)
+	}
+}
+
+func TestCrawlerAsyncWrites(t *testing.T) {
+	server, matchID := fakeRiotAPI(t)
+	defer server.Close()
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1), riot.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "crawl.db")
+	sm, err := storage.NewSQLiteManager(path, storage.WithSQLBatching(100, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Init(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := crawler.NewCrawler(sm, client, "start", 2, crawler.WithMinNumberOfMatches(1), crawler.WithAsyncWrites())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm.OnWrite = func(matchIDs []string, puuids []string) {
+		c.WriteSucceeded(storage.KIND_MATCH, matchIDs)
+		c.WriteSucceeded(storage.KIND_PLAYER, puuids)
+	}
+	sm.OnWriteError = func(matchIDs []string, puuids []string, err error) {
+		c.WriteFailed(storage.KIND_MATCH, matchIDs, err)
+		c.WriteFailed(storage.KIND_PLAYER, puuids, err)
+	}
+	// The match only counts as crawled once its batch has been committed
+	c.Start()
+	if err := sm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if c.NumMatches() != 1 {
+		t.Errorf("Expected the written match to count as crawled, got %d matches", c.NumMatches())
+	}
+	sm, err = storage.NewSQLiteManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Close()
+	if exists, err := sm.MatchExists(matchID); err != nil || !exists {
+		t.Errorf("Expected match %v to be stored (%v)", matchID, err)
+	}
+}
+
+// failingArchive is a MemoryManager whose archive is unavailable
+type failingArchive struct {
+	*storage.MemoryManager
+}
+
+func (fa failingArchive) InsertRaw(doc storage.RawDocument) error {
+	return errors.New("archive unavailable")
+}
+
+func TestCrawlerRawArchiveFailure(t *testing.T) {
+	server, matchID := fakeRiotAPI(t)
+	defer server.Close()
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1), riot.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A match whose raw response could not be stored is neither counted nor lost
+	path := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	var c *crawler.Crawler
+	c, err = crawler.NewCrawler(failingArchive{storage.NewMemoryManager()}, client, "start", 2,
+		crawler.WithMinNumberOfMatches(1),
+		crawler.WithArchive(crawler.ARCHIVE_RAW, storage.GZIP),
+		crawler.WithDeadLetters(path),
+		crawler.WithOnError(func(ctx context.Context, kind string, id string, err error) { c.Stop() }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	if c.NumMatches() != 0 {
+		t.Errorf("A match that could not be archived should not count as crawled")
+	}
+	letters, err := crawler.NewDeadLetters(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) == 0 || letters[0].ID != matchID || letters[0].Stage != crawler.STAGE_STORE || len(letters[0].Doc) == 0 {
+		t.Errorf("Expected match %v to be added to the dead letters with its response, got %+v", matchID, letters)
+	}
+}
+
+func TestCrawlerCapabilities(t *testing.T) {
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A composite manager implements every capability, but its sinks decide whether it is able to use them
+	cm, err := storage.NewCompositeManager(storage.Sink{Name: "counting", Policy: storage.REQUIRED, DBManager: &countingManager{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, opt := range map[string]crawler.Option{
+		"tft":        crawler.WithTFT(),
+		"clash":      crawler.WithClash(),
+		"challenges": crawler.WithChallenges(),
+		"archive":    crawler.WithArchive(crawler.ARCHIVE_BOTH, storage.GZIP),
+	} {
+		if _, err := crawler.NewCrawler(cm, client, "start", 1, opt); err == nil {
+			t.Errorf("Expected %v to be rejected without a sink supporting it", name)
+		}
+	}
+}
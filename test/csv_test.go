@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"strconv"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+
+	// info.participants.* refers to the participant of the row like participants.*
+	paths, err := storage.ParseFieldPaths("metadata.matchId, info.gameVersion,info.participants.championName,participants.win,teams.objectives.baron.kills", storage.LEVEL_PARTICIPANT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scopes := []string{"metadata", "info", "participants", "participants", "teams"}
+	for i, p := range paths {
+		if p.Scope != scopes[i] {
+			t.Errorf("Expected field path %v to refer to %v, got %v", p.Name, scopes[i], p.Scope)
+		}
+	}
+	if paths[1].Name != "info.gameVersion" || fmt.Sprint(paths[2].Keys) != "[championName]" {
+		t.Errorf("Unexpected field paths %+v", paths)
+	}
+	for _, invalid := range []struct{ list, level string }{
+		{"participants.kills", storage.LEVEL_TEAM},
+		{"info.participants.kills", storage.LEVEL_TEAM},
+		{"gameVersion", storage.LEVEL_PARTICIPANT},
+		{"players.kills", storage.LEVEL_PARTICIPANT},
+		{"info.gameVersion", "player"},
+	} {
+		if _, err := storage.ParseFieldPaths(invalid.list, invalid.level); err == nil {
+			t.Errorf("Expected %v to be rejected for %v-level rows", invalid.list, invalid.level)
+		}
+	}
+
+	// A row per participant, carrying the columns of the match and of the participant's team
+	var buf bytes.Buffer
+	cw := storage.NewCSVWriter(&buf, paths, storage.LEVEL_PARTICIPANT)
+	if err := cw.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.InsertMatch(match); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cw.Rows() != len(match.Info.Participants) || len(records) != len(match.Info.Participants)+1 {
+		t.Fatalf("Expected a row per participant, got %d", cw.Rows())
+	}
+	if records[0][2] != "info.participants.championName" {
+		t.Errorf("Unexpected header %v", records[0])
+	}
+	baron := map[int]int{}
+	for _, team := range match.Info.Teams {
+		baron[team.Teamid] = team.Objectives.Baron.Kills
+	}
+	for i, p := range match.Info.Participants {
+		expected := []string{match.MetaData.MatchID, match.Info.GameVersion, p.Championname, strconv.FormatBool(p.Win), strconv.Itoa(baron[p.Teamid])}
+		if fmt.Sprint(records[i+1]) != fmt.Sprint(expected) {
+			t.Errorf("Expected row %v of participant %d, got %v", expected, p.Participantid, records[i+1])
+		}
+	}
+
+	// A row per team
+	paths, err = storage.ParseFieldPaths("metadata.matchId,teams.teamId,info.teams.win,teams.objectives.baron.kills", storage.LEVEL_TEAM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	cw = storage.NewCSVWriter(&buf, paths, storage.LEVEL_TEAM)
+	if err := cw.InsertMatch(match); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	records, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(match.Info.Teams) {
+		t.Fatalf("Expected a row per team, got %d", len(records))
+	}
+	for i, team := range match.Info.Teams {
+		expected := []string{match.MetaData.MatchID, strconv.Itoa(team.Teamid), strconv.FormatBool(team.Win), strconv.Itoa(team.Objectives.Baron.Kills)}
+		if fmt.Sprint(records[i]) != fmt.Sprint(expected) {
+			t.Errorf("Expected row %v of team %d, got %v", expected, team.Teamid, records[i])
+		}
+	}
+}
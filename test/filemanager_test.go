@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileManager(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+
+	dir := t.TempDir()
+	fm, err := storage.NewFileManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fm.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := fm.InsertMatch(match); err != nil {
+			t.Fatalf("Error at writing test match: %v", err)
+		}
+	}
+	partition := filepath.Join(dir, "matches", "date=2021-09-14", "platform="+match.Info.PlatformID, "patch="+match.Patch())
+	if files, _ := filepath.Glob(filepath.Join(partition, "*.jsonl.gz")); len(files) != 0 {
+		t.Errorf("Expected no finalized files before closing, got %v", files)
+	}
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Error at finalizing files: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(partition, "*"))
+	if len(files) != 1 || filepath.Ext(files[0]) != ".gz" {
+		t.Fatalf("Expected exactly one finalized file in %v, got %v", partition, files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Finalized file is no gzip file: %v", err)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1<<20), 1<<22)
+	for scanner.Scan() {
+		decoded := types.Match{}
+		if err := json.Unmarshal(scanner.Bytes(), &decoded); err != nil {
+			t.Fatalf("Error at decoding line %d: %v", lines, err)
+		}
+		if decoded.MetaData.MatchID != match.MetaData.MatchID {
+			t.Errorf("Expected match %v, got %v", match.MetaData.MatchID, decoded.MetaData.MatchID)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"encoding/json"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"sync"
+	"testing"
+)
+
+func TestMemoryManager(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+
+	mm := storage.NewMemoryManager()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mm.InsertMatch(match)
+			for _, puuid := range match.MetaData.Participants {
+				mm.InsertPlayer(types.Summoner{Puuid: puuid})
+			}
+		}()
+	}
+	wg.Wait()
+
+	counts := mm.Counts()
+	if counts[storage.MATCH_COLLECTION] != 1 || counts[storage.PLAYER_COLLECTION] != len(match.MetaData.Participants) {
+		t.Errorf("Expected 1 match and %d players, got %v", len(match.MetaData.Participants), counts)
+	}
+	if exists, _ := mm.MatchExists(match.MetaData.MatchID); !exists {
+		t.Errorf("Expected match %v to exist", match.MetaData.MatchID)
+	}
+	if n, _ := mm.CountMatches(storage.MatchQuery{Puuid: match.MetaData.Participants[0], Patch: match.Patch()}); n != 1 {
+		t.Errorf("Expected to find the match of its participant, got %d matches", n)
+	}
+	if _, ok := mm.Player(match.MetaData.Participants[0]); !ok {
+		t.Errorf("Expected player %v to be stored", match.MetaData.Participants[0])
+	}
+	mm.Reset()
+	if len(mm.Matches()) != 0 || len(mm.Players()) != 0 {
+		t.Errorf("Expected no documents after reset")
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"net"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestMigrateLegacyMatch(t *testing.T) {
+	// Match as it has been stored before the bson tags have been aligned
+	legacy := bson.M{
+		"metaData": bson.M{"dataversion": "2", "matchid": "EUW1_5460889665"},
+		"info": bson.M{
+			"gameVersion":  "11.18.395.7538",
+			"participants": bson.A{bson.M{"baronkills": 1, "nexuslost": 1, "championname": "Shen"}},
+			"teams":        bson.A{bson.M{"teamid": 100, "bans": bson.A{bson.M{"championid": 21, "pickturn": 1}}, "objectives": bson.M{"first": false, "kills": 0}}},
+		},
+	}
+	migrations := storage.Migrations(storage.KIND_MATCH)
+	if len(migrations) == 0 || migrations[0].From != 0 {
+		t.Fatalf("No migration registered for legacy matches")
+	}
+	doc, err := migrations[0].Up(legacy)
+	if err != nil {
+		t.Fatalf("Error at migrating legacy match: %v", err)
+	}
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Error at encoding migrated match: %v", err)
+	}
+	match := types.Match{}
+	if err := bson.Unmarshal(raw, &match); err != nil {
+		t.Fatalf("Error at decoding migrated match: %v", err)
+	}
+	if match.MetaData.MatchID != "EUW1_5460889665" || match.MetaData.Dataversion != "2" {
+		t.Errorf("Metadata migrated incorrectly: %+v", match.MetaData)
+	}
+	p := match.Info.Participants[0]
+	if p.Baronkills != 1 || p.Nexuslost != 1 || p.Championname != "Shen" {
+		t.Errorf("Participant migrated incorrectly: %+v", p)
+	}
+	team := match.Info.Teams[0]
+	if team.Teamid != 100 || team.Bans[0].ChampionId != 21 || team.Bans[0].PickTurn != 1 {
+		t.Errorf("Team migrated incorrectly: %+v", team)
+	}
+}
+
+func TestInitLegacyDatabase(t *testing.T) {
+	if conn, err := net.DialTimeout("tcp", net.JoinHostPort(localhost, "27017"), time.Second); err != nil {
+		t.Skipf("No test DB available on %v: %v", localhost, err)
+	} else {
+		conn.Close()
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://"+localhost+":27017"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+	db := client.Database(dbname + "-legacy")
+	db.Drop(ctx)
+	defer db.Drop(ctx)
+
+	// Matches as they have been stored before the bson tags have been aligned, lacking metaData.matchId
+	for _, id := range []string{"EUW1_1", "EUW1_2"} {
+		if _, err := db.Collection(matchCollection).InsertOne(ctx, bson.M{"metaData": bson.M{"matchid": id}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	legacy, err := storage.NewMManager(localhost, dbname+"-legacy", matchCollection, playerCollection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer legacy.Close()
+	if err := legacy.Init(); err != nil {
+		t.Fatalf("Expected the legacy matches to be migrated before the unique indexes are created: %v", err)
+	}
+
+	// Crawling a legacy match again does not duplicate it
+	match := types.Match{}
+	match.MetaData.MatchID = "EUW1_1"
+	if err := legacy.InsertMatch(match); err != nil {
+		t.Fatal(err)
+	}
+	n, err := db.Collection(matchCollection).CountDocuments(ctx, bson.M{"metaData.matchId": "EUW1_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected the legacy match to be stored once, got %d", n)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"go-league-crawler/pkg/storage"
 	types "go-league-crawler/pkg/types/lol"
 	"io/ioutil"
+	"net"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,9 +29,28 @@ var (
 	matchCollection         = "matches"
 	playerCollection        = "player"
 	// DB
-	mm = storage.NewMManager(localhost, dbname, matchCollection, playerCollection)
+	mm      *storage.MongoManager
+	mmInit  sync.Once
+	mmError error
 )
 
+// initMongo connects the MongoManager once for all tests using it and skips the test if no MongoDB is running locally
+func initMongo(t *testing.T) {
+	if conn, err := net.DialTimeout("tcp", net.JoinHostPort(localhost, "27017"), time.Second); err != nil {
+		t.Skipf("No test DB available on %v: %v", localhost, err)
+	} else {
+		conn.Close()
+	}
+	mmInit.Do(func() {
+		if mm, mmError = storage.NewMManager(localhost, dbname, matchCollection, playerCollection); mmError == nil {
+			mmError = mm.Init()
+		}
+	})
+	if mmError != nil {
+		t.Fatalf("Error at connecting to the test DB: %v", mmError)
+	}
+}
+
 func TestInsertMatch(t *testing.T) {
 	// Reading and decoding test file (match)
 	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
@@ -43,6 +64,7 @@ func TestInsertMatch(t *testing.T) {
 	}
 
 	//Conducting test
+	initMongo(t)
 	err = mm.InsertMatch(match)
 	if err != nil {
 		t.Fatalf("Error at inserting test match!")
@@ -62,6 +84,7 @@ func TestInsertPlayer(t *testing.T) {
 	}
 
 	//Conducting test
+	initMongo(t)
 	err = mm.InsertPlayer(sum)
 	if err != nil {
 		t.Fatalf("Error at inserting test match!")
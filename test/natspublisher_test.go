@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker is an in-process stand-in for a NATS broker, implementing the part of the protocol a publisher uses
+type fakeBroker struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []storage.Message
+	// rejected are the keys of the messages the broker answers with -ERR
+	rejected map[string]bool
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return &fakeBroker{ln: ln}
+}
+
+// serve starts accepting connections. Before, connections are established but never greeted
+func (b *fakeBroker) serve() {
+	go func() {
+		for {
+			conn, err := b.ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.handle(conn)
+		}
+	}()
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"headers\":true,\"max_payload\":1048576}\r\n")
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case fields[0] == "HPUB" && len(fields) == 4:
+			hlen, _ := strconv.Atoi(fields[2])
+			tlen, _ := strconv.Atoi(fields[3])
+			buf := make([]byte, tlen+2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			msg := storage.Message{Subject: fields[1], Data: buf[hlen:tlen]}
+			for _, h := range strings.Split(string(buf[:hlen]), "\r\n") {
+				if strings.HasPrefix(h, "Key: ") {
+					msg.Key = strings.TrimPrefix(h, "Key: ")
+				}
+			}
+			b.mu.Lock()
+			if b.rejected[msg.Key] {
+				fmt.Fprintf(conn, "-ERR 'Permissions Violation for Publish to %v'\r\n", msg.Subject)
+			} else {
+				b.messages = append(b.messages, msg)
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+func (b *fakeBroker) received() []storage.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]storage.Message{}, b.messages...)
+}
+
+func TestNATSPublisher(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+
+	broker := newFakeBroker(t)
+	outbox := filepath.Join(t.TempDir(), "outbox.jsonl")
+	np, err := storage.NewNATSPublisher(broker.ln.Addr().String(),
+		storage.WithOutbox(outbox), storage.WithNATSTimeouts(200*time.Millisecond, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer np.Close()
+
+	// The broker does not answer yet, so the match is kept in the outbox
+	if err := np.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := np.InsertMatch(match); err != nil {
+		t.Fatalf("Error at publishing test match: %v", err)
+	}
+	if _, err := os.Stat(outbox); err != nil {
+		t.Fatalf("Expected the test match to be kept in the outbox: %v", err)
+	}
+
+	// Once the broker is available, the outbox is delivered before the next message
+	broker.serve()
+	if err := np.InsertPlayer(types.Summoner{Puuid: "puuid-1", Name: "dwaynehart"}); err != nil {
+		t.Fatalf("Error at publishing test player: %v", err)
+	}
+	messages := broker.received()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Subject != storage.DefaultMatchSubject || messages[0].Key != match.MetaData.MatchID {
+		t.Errorf("Expected the test match first, got %v (%v)", messages[0].Key, messages[0].Subject)
+	}
+	decoded := types.Match{}
+	if err := json.Unmarshal(messages[0].Data, &decoded); err != nil || decoded.MetaData.MatchID != match.MetaData.MatchID {
+		t.Errorf("Could not decode published match: %v", err)
+	}
+	if messages[1].Subject != storage.DefaultPlayerSubject || messages[1].Key != "puuid-1" {
+		t.Errorf("Expected the test player second, got %v (%v)", messages[1].Key, messages[1].Subject)
+	}
+	if _, err := os.Stat(outbox); !os.IsNotExist(err) {
+		t.Errorf("Expected the outbox to be removed once delivered: %v", err)
+	}
+}
+
+func TestNATSPublisherRejections(t *testing.T) {
+	broker := newFakeBroker(t)
+	broker.rejected = map[string]bool{"poison": true}
+	broker.serve()
+	outbox := filepath.Join(t.TempDir(), "outbox.jsonl")
+	lines := []string{
+		`{"subject":"lol.matches","key":"EUW1_1","data":"e30="}`,
+		`{"subject":"lol.matches","key":`,
+		`{"subject":"lol.matches","key":"poison","data":"e30="}`,
+		`{"subject":"lol.matches","key":"EUW1_2","data":"e30="}`,
+	}
+	if err := ioutil.WriteFile(outbox, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	np, err := storage.NewNATSPublisher(broker.ln.Addr().String(),
+		storage.WithOutbox(outbox), storage.WithNATSTimeouts(200*time.Millisecond, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer np.Close()
+
+	// The corrupt line and the rejected message do not keep the others from being delivered
+	if err := np.Init(); err != nil {
+		t.Fatal(err)
+	}
+	received := map[string]bool{}
+	for _, msg := range broker.received() {
+		received[msg.Key] = true
+	}
+	if !received["EUW1_1"] || !received["EUW1_2"] || len(received) != 2 {
+		t.Errorf("Expected the valid messages of the outbox to be delivered, got %v", received)
+	}
+	if _, err := os.Stat(outbox); !os.IsNotExist(err) {
+		t.Errorf("Expected the outbox to be removed once delivered: %v", err)
+	}
+
+	// A rejected message is not kept in the outbox, where it would be rejected again
+	if err := np.Publish(storage.Message{Subject: "lol.matches", Key: "poison"}); err != nil {
+		t.Errorf("Expected the rejected message to be kept in the reject file: %v", err)
+	}
+	if _, err := os.Stat(outbox); !os.IsNotExist(err) {
+		t.Errorf("Expected no outbox after a rejection: %v", err)
+	}
+	data, err := ioutil.ReadFile(np.RejectFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejections := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(rejections) != 3 {
+		t.Fatalf("Expected 3 rejections, got %d", len(rejections))
+	}
+	rejection := storage.Rejection{}
+	if err := json.Unmarshal([]byte(rejections[0]), &rejection); err != nil || rejection.Line != lines[1] {
+		t.Errorf("Expected the corrupt line to be rejected first, got %+v (%v)", rejection, err)
+	}
+	rejection = storage.Rejection{}
+	if err := json.Unmarshal([]byte(rejections[2]), &rejection); err != nil || rejection.Message == nil || rejection.Message.Key != "poison" {
+		t.Errorf("Expected the published message to be rejected last, got %+v (%v)", rejection, err)
+	}
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParquetManager(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+
+	rows := storage.ParticipantRows(&match)
+	if len(rows) != len(match.Info.Participants) {
+		t.Fatalf("Expected %d rows, got %d", len(match.Info.Participants), len(rows))
+	}
+	for _, row := range rows {
+		if row.Patch != "11.18" || row.Keystone == 0 || row.SubStyle == 0 {
+			t.Errorf("Row of participant %d lacks match or perk columns: %+v", row.ParticipantID, row)
+		}
+	}
+
+	dir := t.TempDir()
+	pm, err := storage.NewParquetManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.InsertMatch(match); err != nil {
+		t.Fatalf("Error at writing test match: %v", err)
+	}
+	if err := pm.Close(); err != nil {
+		t.Fatalf("Error at finalizing files: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "patch=11.18", "*.parquet"))
+	if len(files) != 1 {
+		t.Fatalf("Expected exactly one Parquet file, got %v", files)
+	}
+	data, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Errorf("%v is no Parquet file", files[0])
+	}
+
+	// Files are finalized once they reached their maximum age, without waiting for the manager to be closed
+	dir = t.TempDir()
+	pm, err = storage.NewParquetManager(dir, storage.WithMaxAge(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer pm.Close()
+	if err := pm.InsertMatch(match); err != nil {
+		t.Fatalf("Error at writing test match: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	files, _ = filepath.Glob(filepath.Join(dir, "patch=11.18", "*.parquet"))
+	if len(files) != 1 {
+		t.Errorf("Expected the Parquet file to be finalized by its age, got %v", files)
+	}
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"go-league-crawler/pkg/riot"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRiotClient(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	requested := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested[r.URL.Path] = r.Header.Get("X-Riot-Token")
+		switch r.URL.Path {
+		case "/lol/match/v5/matches/EUW1_5413144108":
+			w.Write(jsonMatch)
+		case "/riot/account/v1/accounts/by-riot-id/ben trades/EUW":
+			w.Write([]byte(`{"puuid":"p1","gameName":"ben trades","tagLine":"EUW"}`))
+		case "/tft/summoner/v1/summoners/by-name/ben trades":
+			w.Write([]byte(`{"puuid":"p1","name":"ben trades"}`))
+		case "/lol/champion-mastery/v4/scores/by-puuid/p1":
+			w.Write([]byte(`42`))
+		case "/lol/league/v4/entries/by-puuid/p1":
+			w.Write([]byte(`[{"queueType":"RANKED_SOLO_5x5","tier":"GOLD","rank":"II","leaguePoints":50}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	archived := map[string]int{}
+	client, err := riot.NewClient("EUW", rate.NewLimiter(rate.Inf, 1),
+		riot.WithBaseURL(server.URL),
+		riot.WithAPIKey("test-key"),
+		riot.WithResponseHook(func(kind string, id string, data []byte) { archived[kind+"/"+id] = len(data) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Platform() != "EUW1" || client.Region() != "europe" {
+		t.Errorf("Unexpected routing values %v and %v", client.Platform(), client.Region())
+	}
+	for marker, routing := range map[string][2]string{"NA": {"NA1", "americas"}, "KR": {"KR", "asia"}, "TR": {"TR1", "europe"}, "OC": {"OC1", "sea"}, "VN": {"VN2", "sea"}} {
+		c, err := riot.NewClient(marker, rate.NewLimiter(rate.Inf, 1))
+		if err != nil {
+			t.Errorf("Region %v should be supported: %v", marker, err)
+		} else if c.Platform() != routing[0] || c.Region() != routing[1] {
+			t.Errorf("Unexpected routing values %v and %v of region %v", c.Platform(), c.Region(), marker)
+		}
+	}
+
+	match, err := client.GetMatch("EUW1_5413144108")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.MetaData.MatchID == "" || len(match.Info.Participants) == 0 {
+		t.Errorf("The match has not been decoded")
+	}
+	if archived["match/EUW1_5413144108"] != len(jsonMatch) {
+		t.Errorf("The response hook has not been given the body of the match")
+	}
+	if requested["/lol/match/v5/matches/EUW1_5413144108"] != "test-key" {
+		t.Errorf("The api key has not been sent")
+	}
+
+	// Players requested by another key than their puuid are archived under their puuid
+	tftPlayer, err := client.GetTFTPlayerByName("ben trades")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tftPlayer.Puuid != "p1" || archived["tft-player/p1"] == 0 {
+		t.Errorf("The response hook has not been given the body of the tft player %+v", tftPlayer)
+	}
+
+	account, err := client.GetAccountByRiotID("ben trades", "EUW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Puuid != "p1" {
+		t.Errorf("Unexpected account %+v", account)
+	}
+	score, err := client.GetMasteryScore(account.Puuid)
+	if err != nil || score != 42 {
+		t.Errorf("Unexpected mastery score %v (%v)", score, err)
+	}
+	entries, err := client.GetLeagueEntries(account.Puuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*entries) != 1 || (*entries)[0].QueueType != types.QueueRankedSolo {
+		t.Errorf("Unexpected league entries %+v", *entries)
+	}
+
+	_, err = client.GetActiveGame(account.Puuid)
+	if !riot.IsNotFound(err) {
+		t.Errorf("A player that is not in a game should be reported as not found, got %v", err)
+	}
+
+	if _, err := riot.NewClient("XX", rate.NewLimiter(rate.Inf, 1)); err == nil {
+		t.Errorf("An unknown region should be rejected")
+	}
+}
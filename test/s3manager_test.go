@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeObjectStorage is an in-process stand-in for an S3-compatible object storage with a single bucket, accepting signed requests
+type fakeObjectStorage struct {
+	mu       sync.Mutex
+	bucket   string
+	objects  map[string][]byte
+	metadata map[string]string
+}
+
+func (s *fakeObjectStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	if r.URL.Path != "/"+s.bucket && !strings.HasPrefix(r.URL.Path, "/"+s.bucket+"/") {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodHead && r.URL.Path == "/"+s.bucket:
+	case r.Method == http.MethodGet && r.URL.Path == "/"+s.bucket:
+		prefix := "/" + s.bucket + "/" + r.URL.Query().Get("prefix")
+		fmt.Fprint(w, "<ListBucketResult><IsTruncated>false</IsTruncated>")
+		for path := range s.objects {
+			if strings.HasPrefix(path, prefix) {
+				fmt.Fprintf(w, "<Contents><Key>%v</Key></Contents>", strings.TrimPrefix(path, "/"+s.bucket+"/"))
+			}
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Amz-Meta-Fetched-At", s.metadata[r.URL.Path])
+		w.Write(data)
+	case r.Method == http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		s.objects[r.URL.Path] = data
+		s.metadata[r.URL.Path] = r.Header.Get("X-Amz-Meta-Fetched-At")
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Manager(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+
+	fake := &fakeObjectStorage{bucket: "crawls", objects: map[string][]byte{}, metadata: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	if missing, _ := storage.NewS3Manager(server.URL, "missing", "euw", storage.WithS3Credentials("minio", "minio123")); missing.Init() == nil {
+		t.Errorf("A missing bucket should fail Init")
+	}
+	sm, err := storage.NewS3Manager(server.URL, "crawls", "euw", storage.WithS3Credentials("minio", "minio123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Init(); err != nil {
+		t.Fatalf("Error at checking the bucket: %v", err)
+	}
+	if err := sm.InsertMatch(match); err != nil {
+		t.Fatalf("Error at writing test match: %v", err)
+	}
+	if err := sm.Close(); err != nil {
+		t.Fatalf("Error at writing manifest: %v", err)
+	}
+
+	key := "euw/matches/platform=EUW1/patch=11.18/date=2021-09-14/" + match.MetaData.MatchID + ".json.gz"
+	data, ok := fake.objects["/crawls/"+key]
+	if !ok {
+		t.Fatalf("Expected object %v, got %v", key, fake.objects)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Object is not compressed: %v", err)
+	}
+	decoded := types.Match{}
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil || decoded.MetaData.MatchID != match.MetaData.MatchID {
+		t.Errorf("Could not decode stored match: %v", err)
+	}
+
+	manifests := 0
+	for path, data := range fake.objects {
+		if !strings.HasPrefix(path, "/crawls/euw/manifests/") {
+			continue
+		}
+		manifests++
+		manifest := storage.Manifest{}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("Could not decode manifest: %v", err)
+		}
+		if manifest.Count != 1 || manifest.Keys[0] != key {
+			t.Errorf("Expected the manifest to list %v, got %v", key, manifest.Keys)
+		}
+	}
+	if manifests != 1 {
+		t.Errorf("Expected 1 manifest, got %d", manifests)
+	}
+
+	// Raw api responses are archived below raw/ and read back as they are compressed
+	if !storage.Supports(sm, storage.CAPABILITY_ARCHIVE) {
+		t.Fatalf("S3Manager should support the raw archive")
+	}
+	raw, err := storage.NewRawDocument(storage.KIND_MATCH, match.MetaData.MatchID, storage.ZSTD, jsonMatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.InsertRaw(raw); err != nil {
+		t.Fatalf("Error at archiving test match: %v", err)
+	}
+	if _, ok := fake.objects["/crawls/euw/raw/match/"+match.MetaData.MatchID+".json.zst"]; !ok {
+		t.Errorf("Expected the raw object below euw/raw/match/, got %v", fake.objects)
+	}
+	docs := []storage.RawDocument{}
+	if err := sm.IterateRaw(storage.KIND_MATCH, func(doc storage.RawDocument) error {
+		docs = append(docs, doc)
+		return nil
+	}); err != nil {
+		t.Fatalf("Error at iterating the raw archive: %v", err)
+	}
+	if len(docs) != 1 || docs[0].ID != raw.ID || docs[0].Compression != storage.ZSTD || docs[0].FetchedAt != raw.FetchedAt {
+		t.Fatalf("Expected the archived match, got %+v", docs)
+	}
+	if data, err := docs[0].Bytes(); err != nil || !bytes.Equal(data, jsonMatch) {
+		t.Errorf("The archived match differs from the response (%v)", err)
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"encoding/json"
+	"go-league-crawler/pkg/storage"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteManager(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+	jsonPlayer, err := ioutil.ReadFile("./data/player/dwaynehart.json")
+	if err != nil {
+		t.Fatalf("Reading test file (player) failed!")
+	}
+	player := types.Summoner{}
+	if err := json.Unmarshal(jsonPlayer, &player); err != nil {
+		t.Fatalf("Error at Decoding test file (player)!")
+	}
+
+	sm, err := storage.NewSQLiteManager(filepath.Join(t.TempDir(), "crawl.db"), storage.WithSQLBatching(1, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Init(); err != nil {
+		t.Fatalf("Error at opening test DB: %v", err)
+	}
+	defer sm.Close()
+	// Inserting twice must not duplicate any row
+	for i := 0; i < 2; i++ {
+		if err := sm.InsertMatch(match); err != nil {
+			t.Fatalf("Error at inserting test match: %v", err)
+		}
+		if err := sm.InsertPlayer(player); err != nil {
+			t.Fatalf("Error at inserting test player: %v", err)
+		}
+	}
+
+	counts := map[string]int{
+		"matches":      1,
+		"participants": len(match.Info.Participants),
+		"teams":        len(match.Info.Teams),
+		"players":      1,
+		"player_names": 1,
+	}
+	for table, expected := range counts {
+		var n int
+		if err := sm.Conn.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+			t.Fatalf("Error at counting %v: %v", table, err)
+		}
+		if n != expected {
+			t.Errorf("Expected %d rows in %v, got %d", expected, table, n)
+		}
+	}
+	var kills int
+	err = sm.Conn.QueryRow("SELECT kills FROM participants WHERE match_id = ? AND puuid = ?",
+		match.MetaData.MatchID, match.Info.Participants[0].Puuid).Scan(&kills)
+	if err != nil || kills != match.Info.Participants[0].Kills {
+		t.Errorf("Expected %d kills of the first participant, got %d (%v)", match.Info.Participants[0].Kills, kills, err)
+	}
+
+	stored, err := sm.GetMatch(match.MetaData.MatchID)
+	if err != nil || stored.Info.GameID != match.Info.GameID {
+		t.Errorf("Expected to read back match %v, got %v (%v)", match.MetaData.MatchID, stored.MetaData.MatchID, err)
+	}
+	if _, err := sm.GetMatch("EUW1_0"); err != storage.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for an unknown match, got %v", err)
+	}
+	day := time.Date(2021, 9, 14, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		query    storage.MatchQuery
+		expected int64
+	}{
+		{storage.MatchQuery{Puuid: match.MetaData.Participants[0], QueueID: match.Info.QueueID, Patch: match.Patch()}, 1},
+		{storage.MatchQuery{Puuid: "unknown"}, 0},
+		{storage.MatchQuery{Since: day, Until: day.AddDate(0, 0, 1)}, 1},
+		{storage.MatchQuery{Since: day.AddDate(0, 0, 1)}, 0},
+	} {
+		if n, err := sm.CountMatches(c.query); err != nil || n != c.expected {
+			t.Errorf("Expected %d matches for %+v, got %d (%v)", c.expected, c.query, n, err)
+		}
+	}
+}
+
+func TestSQLiteManagerFailedBatch(t *testing.T) {
+	sm, err := storage.NewSQLiteManager(filepath.Join(t.TempDir(), "crawl.db"), storage.WithSQLBatching(2, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Init(); err != nil {
+		t.Fatalf("Error at opening test DB: %v", err)
+	}
+	defer sm.Close()
+	failed := []string{}
+	sm.OnWriteError = func(matchIDs []string, puuids []string, err error) { failed = append(failed, puuids...) }
+
+	if err := sm.InsertPlayer(types.Summoner{Puuid: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.Conn.Exec("DROP TABLE player_names"); err != nil {
+		t.Fatal(err)
+	}
+	// The batch is full with the second player and fails as a whole
+	if err := sm.InsertPlayer(types.Summoner{Puuid: "p2", Name: "name"}); err != nil {
+		t.Errorf("The failure of a batch should be passed to OnWriteError, got %v", err)
+	}
+	if len(failed) != 2 || failed[0] != "p1" || failed[1] != "p2" {
+		t.Errorf("Expected the players p1 and p2 to be reported, got %v", failed)
+	}
+}
+
+func TestSQLiteManagerBatchLookups(t *testing.T) {
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match)!")
+	}
+	sm, err := storage.NewSQLiteManager(filepath.Join(t.TempDir(), "crawl.db"), storage.WithSQLBatching(2, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Init(); err != nil {
+		t.Fatalf("Error at opening test DB: %v", err)
+	}
+	defer sm.Close()
+	if err := sm.InsertMatch(match); err != nil {
+		t.Fatal(err)
+	}
+
+	// Point lookups leave the pending batch alone, queries flush it
+	if ok, err := sm.MatchExists(match.MetaData.MatchID); err != nil || ok {
+		t.Errorf("Expected the pending match not to be flushed by a point lookup (%v)", err)
+	}
+	if n, err := sm.CountMatches(storage.MatchQuery{}); err != nil || n != 1 {
+		t.Errorf("Expected the pending match to be flushed by a query, got %d (%v)", n, err)
+	}
+	if ok, err := sm.MatchExists(match.MetaData.MatchID); err != nil || !ok {
+		t.Errorf("Expected the flushed match to exist (%v)", err)
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	types "go-league-crawler/pkg/types/lol"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeMatch(t *testing.T) {
+	// Reading and decoding test file (match), every field of the response needs to be known
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	dec := json.NewDecoder(bytes.NewReader(jsonMatch))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&match); err != nil {
+		t.Fatalf("Error at Decoding test file (match): %v", err)
+	}
+
+	if match.MetaData.MatchID != "EUW1_5460889665" || match.MetaData.Dataversion != "2" {
+		t.Errorf("Unexpected metadata %+v", match.MetaData)
+	}
+	if len(match.Info.Participants) != 10 || len(match.Info.Teams) != 2 {
+		t.Fatalf("Expected 10 participants in 2 teams, got %d in %d", len(match.Info.Participants), len(match.Info.Teams))
+	}
+	objectives := match.Info.Teams[0].Objectives
+	if objectives.Tower.Kills != 11 || !objectives.Tower.First || objectives.Dragon.Kills != 2 || objectives.Champion.Kills != 32 || !objectives.Riftherald.First {
+		t.Errorf("Objectives decoded incorrectly: %+v", objectives)
+	}
+	shen := match.Info.Participants[0]
+	if shen.Championname != "Shen" || shen.Perks.Styles[0].Style != 8400 || shen.Perks.StatPerks.Flex != 5008 {
+		t.Errorf("Participant decoded incorrectly: %+v", shen)
+	}
+}
+
+func TestEncodeMatch(t *testing.T) {
+	// Every field of the test file (match) needs to survive decoding and encoding
+	jsonMatch, err := ioutil.ReadFile("./data/match/EUW1_5413144108.json")
+	if err != nil {
+		t.Fatalf("Reading test file (match) failed!")
+	}
+	match := types.Match{}
+	if err := json.Unmarshal(jsonMatch, &match); err != nil {
+		t.Fatalf("Error at Decoding test file (match): %v", err)
+	}
+	encoded, err := json.Marshal(match)
+	if err != nil {
+		t.Fatalf("Error at Encoding test match: %v", err)
+	}
+	var golden, actual interface{}
+	json.Unmarshal(jsonMatch, &golden)
+	json.Unmarshal(encoded, &actual)
+	assertContains(t, "", golden, actual)
+}
+
+// assertContains checks that every value of the golden document is part of the actual document
+func assertContains(t *testing.T, path string, golden interface{}, actual interface{}) {
+	switch g := golden.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: expected object, got %v", path, actual)
+			return
+		}
+		for k, v := range g {
+			assertContains(t, path+"."+k, v, a[k])
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(g) {
+			t.Errorf("%s: expected %d elements, got %v", path, len(g), actual)
+			return
+		}
+		for i := range g {
+			assertContains(t, path, g[i], a[i])
+		}
+	default:
+		if !reflect.DeepEqual(golden, actual) {
+			t.Errorf("%s: expected %v, got %v", path, golden, actual)
+		}
+	}
+}